@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fireland15/rpc-gen/internal/compiler"
+	"github.com/fireland15/rpc-gen/internal/config"
+	"github.com/fireland15/rpc-gen/internal/syntax"
+)
+
+// Formats definition files in place, returning the exit status. With -check
+// the files which aren't formatted are listed instead, and the status is 1
+// when there are any. Files which can't be formatted give a status of 2.
+func formatCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	check := flags.Bool("check", false, "list the files which aren't formatted instead of formatting them")
+	configPath := flags.String("c", "config.json", "path to config file, whose definition file and its imports are formatted when no files are given")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: rpc-gen fmt [-check] [-c config.json] [files...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	files := flags.Args()
+	if len(files) == 0 {
+		config, err := config.ReadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		service, err := compiler.Load(config.RpcDefinitionFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		files = service.Files
+	}
+
+	status := 0
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		formatted, err := syntax.Format(string(source))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "problem formatting '%s':\n%s\n", file, err)
+			status = 2
+			continue
+		}
+
+		if formatted == string(source) {
+			continue
+		}
+
+		if *check {
+			fmt.Println(file)
+			status = max(status, 1)
+			continue
+		}
+
+		err = os.WriteFile(file, []byte(formatted), 0o644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+		}
+	}
+	return status
+}
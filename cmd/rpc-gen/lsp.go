@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fireland15/rpc-gen/internal/lsp"
+)
+
+// Runs the language server over stdin and stdout, returning the exit status.
+func lspCommand(args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: rpc-gen lsp\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	err := lsp.Serve(os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -3,12 +3,20 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 
 	"github.com/fireland15/rpc-gen/internal/compiler"
 	"github.com/fireland15/rpc-gen/internal/config"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(formatCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		os.Exit(lspCommand(os.Args[2:]))
+	}
+
 	configPath := flag.String("c", "config.json", "path to config file")
 
 	flag.Parse()
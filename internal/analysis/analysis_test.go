@@ -0,0 +1,357 @@
+package analysis
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fireland15/rpc-gen/internal/parser"
+)
+
+// A definition along with the errors checking it should give.
+type checkCase struct {
+	name   string
+	source string
+	errors []string
+}
+
+// Parses the source and returns the errors found by checking it.
+func check(t *testing.T, source string) []string {
+	t.Helper()
+	p, err := parser.NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Check(&service)
+}
+
+func runCheckCases(t *testing.T, cases []checkCase) {
+	t.Helper()
+	for _, c := range cases {
+		errors := check(t, c.source)
+		if !slices.Equal(c.errors, errors) {
+			t.Errorf("%s: expected errors %q, but got %q", c.name, c.errors, errors)
+		}
+	}
+}
+
+var unionCases = []checkCase{
+	{
+		name:   "field named like the discriminator",
+		source: "model Circle { kind string }\nunion Shape(kind) { circle Circle }",
+		errors: []string{":1:16: field 'kind' of model 'Circle' clashes with the discriminator of union 'Shape'."},
+	},
+	{
+		name:   "field renamed to the discriminator",
+		source: "model Circle { shape string @json(\"kind\") }\nunion Shape(kind) { circle Circle }",
+		errors: []string{":1:16: field 'shape' of model 'Circle' clashes with the discriminator of union 'Shape'."},
+	},
+	{
+		name:   "field named like the discriminator but renamed",
+		source: "model Circle { kind string @json(\"circleKind\") }\nunion Shape(kind) { circle Circle }",
+		errors: []string{},
+	},
+	{
+		name:   "discriminator in snake case",
+		source: "model Circle { eventType string }\nunion Shape(event_type) { circle Circle }",
+		errors: []string{":1:16: field 'eventType' of model 'Circle' clashes with the discriminator of union 'Shape'."},
+	},
+}
+
+func TestCheckUnionVariants(t *testing.T) {
+	runCheckCases(t, unionCases)
+}
+
+var routeCases = []checkCase{
+	{
+		name:   "routes differing in the names of their variables",
+		source: "@http(GET, \"/entries/{id}\")\nrpc GetEntry(id int)\n@http(GET, \"/entries/{entryId}\")\nrpc FindEntry(entryId int)",
+		errors: []string{":3:1: RPC 'FindEntry' has the same route as RPC 'GetEntry' (GET /entries/{entryId})"},
+	},
+	{
+		name:   "routes differing in their methods",
+		source: "@http(GET, \"/entries/{id}\")\nrpc GetEntry(id int)\n@http(DELETE, \"/entries/{id}\")\nrpc DeleteEntry(id int)",
+		errors: []string{},
+	},
+	{
+		name:   "route matching a default route",
+		source: "rpc GetEntry(id int)\n@http(POST, \"/get_entry\")\nrpc FindEntry(id int)",
+		errors: []string{":2:1: RPC 'FindEntry' has the same route as RPC 'GetEntry' (POST /get_entry)"},
+	},
+	{
+		name:   "unknown path variable",
+		source: "@http(GET, \"/entries/{entryId}\")\nrpc GetEntry(id int)",
+		errors: []string{
+			":1:1: path variable 'entryId' of RPC 'GetEntry' isn't one of its parameters",
+		},
+	},
+	{
+		name:   "repeated path variable",
+		source: "@http(GET, \"/entries/{id}/copies/{id}\")\nrpc GetEntry(id int)",
+		errors: []string{":1:1: path variable 'id' of RPC 'GetEntry' is used more than once"},
+	},
+	{
+		name:   "non-scalar path parameter",
+		source: "model Filter { tag string }\n@http(GET, \"/entries/{filter}\")\nrpc ListEntries(filter Filter)",
+		errors: []string{":3:17: path parameter 'filter' of RPC 'ListEntries' must be a bool, int, float, string, uuid, date or enum, not 'Filter'"},
+	},
+	{
+		name:   "optional path parameter",
+		source: "@http(GET, \"/entries/{id}\")\nrpc GetEntry(id int?)",
+		errors: []string{":2:14: path parameter 'id' of RPC 'GetEntry' must be a bool, int, float, string, uuid, date or enum, not 'int?'"},
+	},
+	{
+		name:   "enum path parameter and scalar query parameters",
+		source: "enum Status { Draft, Published }\n@http(GET, \"/entries/{status}\")\nrpc ListEntries(status Status, tags string[], limit int?)",
+		errors: []string{},
+	},
+	{
+		name:   "non-scalar query parameter",
+		source: "model Filter { tag string }\n@http(GET, \"/entries\")\nrpc ListEntries(filter Filter)",
+		errors: []string{":3:17: query parameter 'filter' of RPC 'ListEntries' must be a bool, int, float, string, uuid, date or enum, or an optional or array of one, not 'Filter'"},
+	},
+	{
+		name:   "nested array query parameter",
+		source: "@http(DELETE, \"/entries\")\nrpc DeleteEntries(ids int[][])",
+		errors: []string{":2:19: query parameter 'ids' of RPC 'DeleteEntries' must be a bool, int, float, string, uuid, date or enum, or an optional or array of one, not 'int[][]'"},
+	},
+	{
+		name:   "non-scalar parameter sent in the body",
+		source: "model Filter { tag string }\n@http(PUT, \"/entries/{id}\")\nrpc UpdateEntry(id int, filter Filter)",
+		errors: []string{},
+	},
+	{
+		name:   "route on a stream RPC",
+		source: "model Entry { title string }\n@http(GET, \"/entries\")\nrpc WatchEntries() stream Entry",
+		errors: []string{":2:1: @http on RPC 'WatchEntries' can't be used on stream RPCs"},
+	},
+	{
+		name:   "route on a model",
+		source: "@http(GET, \"/entries\")\nmodel Entry { title string }",
+		errors: []string{":1:1: @http on model 'Entry' can only be used on RPCs"},
+	},
+}
+
+func TestCheckRoutes(t *testing.T) {
+	runCheckCases(t, routeCases)
+}
+
+// Errors about a member of a declaration are reported where the member is
+// written.
+var memberCases = []checkCase{
+	{
+		name:   "field of a model",
+		source: "model Entry {\n    id int\n    status Status\n}",
+		errors: []string{":3:5: undefined type 'Status'"},
+	},
+	{
+		name:   "annotated parameter",
+		source: "rpc GetEntry(id int, @json(1) limit int = \"ten\")",
+		errors: []string{
+			":1:22: @json on parameter 'limit' of RPC 'GetEntry' expects the field's JSON name",
+			":1:22: default value \"ten\" of parameter 'limit' of RPC 'GetEntry' doesn't match type 'int'",
+		},
+	},
+	{
+		name:   "enum value",
+		source: "enum Status {\n    Draft,\n    Draft,\n}",
+		errors: []string{":3:5: duplicate value 'Draft' in enum 'Status'."},
+	},
+	{
+		name:   "union variant",
+		source: "model Circle { radius int }\nunion Shape(kind) {\n    circle Circle\n    round  Circle\n}",
+		errors: []string{":4:5: model 'Circle' is used by more than one variant of union 'Shape'."},
+	},
+	{
+		name:   "field of an error",
+		source: "error NotFound {\n    id int = 1\n}",
+		errors: []string{":2:5: field 'id' of error 'NotFound' can't have a default value"},
+	},
+}
+
+func TestCheckReportsMembers(t *testing.T) {
+	runCheckCases(t, memberCases)
+}
+
+const moodEnum = "enum Mood {\n    Happy,\n    Sad,\n}\n"
+
+var defaultCases = []checkCase{
+	{
+		name:   "defaults matching their types",
+		source: moodEnum + "model A {\n    n int = 1\n    f float = 1.5\n    g float = 2\n    b bool = true\n    s string = \"x\"\n    m Mood = Happy\n    o Mood? = Sad\n}",
+		errors: []string{},
+	},
+	{
+		name:   "string for an int",
+		source: "model A { n int = \"1\" }",
+		errors: []string{":1:11: default value \"1\" of field 'n' of model 'A' doesn't match type 'int'"},
+	},
+	{
+		name:   "fraction for an int",
+		source: "model A { n int = 1.5 }",
+		errors: []string{":1:11: default value 1.5 of field 'n' of model 'A' doesn't match type 'int'"},
+	},
+	{
+		name:   "string for a float",
+		source: "model A { f float = \"1\" }",
+		errors: []string{":1:11: default value \"1\" of field 'f' of model 'A' doesn't match type 'float'"},
+	},
+	{
+		name:   "identifier other than true or false for a bool",
+		source: "model A { b bool = yes }",
+		errors: []string{":1:11: default value yes of field 'b' of model 'A' doesn't match type 'bool'"},
+	},
+	{
+		name:   "number for a bool",
+		source: "model A { b bool = 1 }",
+		errors: []string{":1:11: default value 1 of field 'b' of model 'A' doesn't match type 'bool'"},
+	},
+	{
+		name:   "identifier for a string",
+		source: "model A { s string = x }",
+		errors: []string{":1:11: default value x of field 's' of model 'A' doesn't match type 'string'"},
+	},
+	{
+		name:   "unknown enum value",
+		source: moodEnum + "model A { m Mood = Angry }",
+		errors: []string{":5:11: default value Angry of field 'm' of model 'A' isn't a value of enum 'Mood'"},
+	},
+	{
+		name:   "enum value as a string",
+		source: moodEnum + "model A { m Mood = \"Happy\" }",
+		errors: []string{":5:11: default value \"Happy\" of field 'm' of model 'A' isn't a value of enum 'Mood'"},
+	},
+	{
+		name:   "default for an array",
+		source: "model A { tags string[] = \"x\" }",
+		errors: []string{":1:11: default value \"x\" of field 'tags' of model 'A' can't be used, only bool, int, float, string and enum types can have defaults"},
+	},
+	{
+		name:   "default for a map",
+		source: "model A { counts map<string, int> = 1 }",
+		errors: []string{":1:11: default value 1 of field 'counts' of model 'A' can't be used, only bool, int, float, string and enum types can have defaults"},
+	},
+	{
+		name:   "default for a model",
+		source: "model B { n int }\nmodel A { b B = x }",
+		errors: []string{":2:11: default value x of field 'b' of model 'A' can't be used, only bool, int, float, string and enum types can have defaults"},
+	},
+	{
+		name:   "default for a uuid",
+		source: "model A { id uuid = \"x\" }",
+		errors: []string{":1:11: default value \"x\" of field 'id' of model 'A' can't be used, only bool, int, float, string and enum types can have defaults"},
+	},
+	{
+		name:   "parameter default not matching its type",
+		source: "rpc Get(limit int = \"20\")",
+		errors: []string{":1:9: default value \"20\" of parameter 'limit' of RPC 'Get' doesn't match type 'int'"},
+	},
+}
+
+func TestCheckDefaultValues(t *testing.T) {
+	runCheckCases(t, defaultCases)
+}
+
+var mapKeyCases = []checkCase{
+	{
+		name:   "keys which are JSON object keys",
+		source: moodEnum + "model A {\n    a map<string, int>\n    b map<int, int>\n    c map<uuid, int>\n    d map<Mood, int>\n}",
+		errors: []string{},
+	},
+	{
+		name:   "float key",
+		source: "model A { a map<float, int> }",
+		errors: []string{":1:11: field 'a' of model 'A': map key type 'float' can't be used as a JSON object key"},
+	},
+	{
+		name:   "bool key",
+		source: "model A { a map<bool, int> }",
+		errors: []string{":1:11: field 'a' of model 'A': map key type 'bool' can't be used as a JSON object key"},
+	},
+	{
+		name:   "model key",
+		source: "model B { n int }\nmodel A { a map<B, int> }",
+		errors: []string{":2:11: field 'a' of model 'A': map key type 'B' can't be used as a JSON object key"},
+	},
+	{
+		name:   "optional date key",
+		source: "model A { a map<date, int>? }",
+		errors: []string{":1:11: field 'a' of model 'A': map key type 'date' can't be used as a JSON object key"},
+	},
+	{
+		name:   "key of a map nested in an array",
+		source: "model A { a map<string, map<float, int>>[] }",
+		errors: []string{":1:11: field 'a' of model 'A': map key type 'float' can't be used as a JSON object key"},
+	},
+	{
+		name:   "key of an error's field",
+		source: "error E { a map<bool, int> }",
+		errors: []string{":1:11: field 'a' of error 'E': map key type 'bool' can't be used as a JSON object key"},
+	},
+	{
+		name:   "key of a return type",
+		source: "rpc Get() map<float, int>",
+		errors: []string{":1:1: return type of RPC 'Get': map key type 'float' can't be used as a JSON object key"},
+	},
+}
+
+func TestCheckMapKeyTypes(t *testing.T) {
+	runCheckCases(t, mapKeyCases)
+}
+
+var constraintCases = []checkCase{
+	{
+		name:   "constraints fitting their types",
+		source: "model A {\n    s string? @maxLength(1)\n    t string[] @minItems(1)\n    f float @range(0.5, 1.5)\n    n int @range(0, 10)\n}",
+		errors: []string{},
+	},
+	{
+		name:   "string constraint on an int",
+		source: "model A { n int @minLength(1) }",
+		errors: []string{":1:11: @minLength on field 'n' of model 'A' can only be used on strings, not 'int'"},
+	},
+	{
+		name:   "string constraint on an array of strings",
+		source: "model A { t string[] @pattern(\"x\") }",
+		errors: []string{":1:11: @pattern on field 't' of model 'A' can only be used on strings, not 'string[]'"},
+	},
+	{
+		name:   "array constraint on a string",
+		source: "model A { s string @maxItems(1) }",
+		errors: []string{":1:11: @maxItems on field 's' of model 'A' can only be used on arrays, not 'string'"},
+	},
+	{
+		name:   "range on a string",
+		source: "model A { s string @range(1, 2) }",
+		errors: []string{":1:11: @range on field 's' of model 'A' can only be used on ints and floats, not 'string'"},
+	},
+	{
+		name:   "fractional range on an int",
+		source: "model A { n int @range(0.5, 10) }",
+		errors: []string{":1:11: @range on field 'n' of model 'A' expects integer bounds for an int, not 0.5"},
+	},
+	{
+		name:   "constraint on a parameter of the wrong type",
+		source: "rpc Get(n int @maxLength(2))",
+		errors: []string{":1:9: @maxLength on parameter 'n' of RPC 'Get' can only be used on strings, not 'int'"},
+	},
+	{
+		name:   "constraint on a model",
+		source: "@minLength(1)\nmodel A { n int }",
+		errors: []string{":1:1: @minLength on model 'A' can only be used on fields and parameters"},
+	},
+	{
+		name:   "constraint on an RPC",
+		source: "@range(1, 2)\nrpc Get()",
+		errors: []string{":1:1: @range on RPC 'Get' can only be used on fields and parameters"},
+	},
+}
+
+func TestCheckConstraints(t *testing.T) {
+	runCheckCases(t, constraintCases)
+}
@@ -0,0 +1,202 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Checks the arguments of the annotations understood by rpc-gen. Any other
+// annotation is left for generators to interpret.
+var annotationCheckers = map[string]func(arguments []model.Value) error{
+	"deprecated": func(arguments []model.Value) error {
+		if len(arguments) > 1 || (len(arguments) == 1 && arguments[0].Kind != model.ValueKindString) {
+			return errors.New("expects an optional reason string")
+		}
+		return nil
+	},
+	"json": func(arguments []model.Value) error {
+		if len(arguments) != 1 || arguments[0].Kind != model.ValueKindString || arguments[0].Text == "" {
+			return errors.New("expects the field's JSON name")
+		}
+		return nil
+	},
+	"status": func(arguments []model.Value) error {
+		if len(arguments) != 1 || arguments[0].Kind != model.ValueKindNumber {
+			return errors.New("expects an HTTP status code")
+		}
+		if status, err := strconv.Atoi(arguments[0].Text); err != nil || status < 400 || status > 599 {
+			return errors.New("expects an HTTP status code between 400 and 599")
+		}
+		return nil
+	},
+	"http": func(arguments []model.Value) error {
+		if len(arguments) != 2 || arguments[0].Kind != model.ValueKindIdentifier || arguments[1].Kind != model.ValueKindString {
+			return errors.New("expects an HTTP method and a path string")
+		}
+		if !slices.Contains(model.HTTPMethods, arguments[0].Text) {
+			return fmt.Errorf("has an unknown HTTP method '%s', expected one of %s", arguments[0].Text, strings.Join(model.HTTPMethods, ", "))
+		}
+		return checkRoutePath(arguments[1].Text)
+	},
+	"minLength": expectCount,
+	"maxLength": expectCount,
+	"minItems":  expectCount,
+	"maxItems":  expectCount,
+	"pattern": func(arguments []model.Value) error {
+		if len(arguments) != 1 || arguments[0].Kind != model.ValueKindString {
+			return errors.New("expects a regular expression string")
+		}
+		if _, err := regexp.Compile(arguments[0].Text); err != nil {
+			return fmt.Errorf("has an invalid regular expression: %w", err)
+		}
+		return nil
+	},
+	"range": func(arguments []model.Value) error {
+		if len(arguments) != 2 || arguments[0].Kind != model.ValueKindNumber || arguments[1].Kind != model.ValueKindNumber {
+			return errors.New("expects a minimum and maximum number")
+		}
+		low, _ := strconv.ParseFloat(arguments[0].Text, 64)
+		high, _ := strconv.ParseFloat(arguments[1].Text, 64)
+		if low > high {
+			return errors.New("has a minimum greater than its maximum")
+		}
+		return nil
+	},
+}
+
+// checks that the only argument is a non-negative integer
+func expectCount(arguments []model.Value) error {
+	if len(arguments) != 1 || arguments[0].Kind != model.ValueKindNumber {
+		return errors.New("expects a non-negative integer")
+	}
+	if n, err := strconv.Atoi(arguments[0].Text); err != nil || n < 0 {
+		return errors.New("expects a non-negative integer")
+	}
+	return nil
+}
+
+var pathVariablePattern = regexp.MustCompile(`^\{[A-Za-z_][A-Za-z0-9_]*\}$`)
+
+// checks that path starts with a slash and that its variables are whole
+// segments naming identifiers
+func checkRoutePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return errors.New("expects a path starting with '/'")
+	}
+	if strings.ContainsAny(path, "?#") {
+		return errors.New("expects a path without a query string or fragment")
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.ContainsAny(segment, "{}") && !pathVariablePattern.MatchString(segment) {
+			return fmt.Errorf("has an invalid path segment '%s', variables must be whole segments like {name}", segment)
+		}
+	}
+	return nil
+}
+
+// Makes sure that the annotations understood by rpc-gen are used correctly
+func CheckAnnotations(errors *[]string, service model.ServiceDefinition) {
+	check := func(source model.Source, where string, annotations model.Annotations) {
+		for _, annotation := range annotations {
+			checker, found := annotationCheckers[annotation.Name]
+			if !found {
+				continue
+			}
+
+			if err := checker(annotation.Arguments); err != nil {
+				msg := fmt.Sprintf("%s: @%s on %s %s", source, annotation.Name, where, err)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
+
+	for _, m := range service.Models {
+		check(m.Source, fmt.Sprintf("model '%s'", m.Name), m.Annotations)
+		for _, f := range m.Fields {
+			check(f.Source, fmt.Sprintf("field '%s' of model '%s'", f.Name, m.Name), f.Annotations)
+		}
+	}
+
+	for _, e := range service.Enums {
+		check(e.Source, fmt.Sprintf("enum '%s'", e.Name), e.Annotations)
+	}
+
+	for _, u := range service.Unions {
+		check(u.Source, fmt.Sprintf("union '%s'", u.Name), u.Annotations)
+	}
+
+	for _, e := range service.Errors {
+		check(e.Source, fmt.Sprintf("error '%s'", e.Name), e.Annotations)
+		for _, f := range e.Fields {
+			check(f.Source, fmt.Sprintf("field '%s' of error '%s'", f.Name, e.Name), f.Annotations)
+		}
+	}
+
+	for _, s := range service.Services {
+		check(s.Source, fmt.Sprintf("service '%s'", s.Name), s.Annotations)
+	}
+
+	for _, m := range service.Methods {
+		check(m.Source, fmt.Sprintf("RPC '%s'", m.Name), m.Annotations)
+		for _, p := range m.Parameters {
+			check(p.Source, fmt.Sprintf("parameter '%s' of RPC '%s'", p.Name, m.Name), p.Annotations)
+		}
+	}
+}
+
+// Reports the annotations picked out by find on the models, enums, unions,
+// errors, services and RPCs, as they can only be used on the declarations
+// described by usage. The kinds of declaration in allowed, "error" or "RPC",
+// are skipped.
+func checkMisplaced(errors *[]string, service model.ServiceDefinition, usage string, find func(model.Annotations) model.Annotations, allowed ...string) {
+	misplaced := func(source model.Source, kind string, name string, annotations model.Annotations) {
+		if slices.Contains(allowed, kind) {
+			return
+		}
+		for _, annotation := range find(annotations) {
+			msg := fmt.Sprintf("%s: @%s on %s '%s' can only be used on %s", source, annotation.Name, kind, name, usage)
+			*errors = append(*errors, msg)
+		}
+	}
+
+	for _, m := range service.Models {
+		misplaced(m.Source, "model", m.Name, m.Annotations)
+	}
+
+	for _, e := range service.Enums {
+		misplaced(e.Source, "enum", e.Name, e.Annotations)
+	}
+
+	for _, u := range service.Unions {
+		misplaced(u.Source, "union", u.Name, u.Annotations)
+	}
+
+	for _, e := range service.Errors {
+		misplaced(e.Source, "error", e.Name, e.Annotations)
+	}
+
+	for _, s := range service.Services {
+		misplaced(s.Source, "service", s.Name, s.Annotations)
+	}
+
+	for _, m := range service.Methods {
+		misplaced(m.Source, "RPC", m.Name, m.Annotations)
+	}
+}
+
+// Returns a find func for checkMisplaced picking out the first annotation
+// with the given name
+func annotationNamed(name string) func(model.Annotations) model.Annotations {
+	return func(annotations model.Annotations) model.Annotations {
+		if annotation, found := annotations.Find(name); found {
+			return model.Annotations{annotation}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,23 @@
+package analysis
+
+import "github.com/fireland15/rpc-gen/internal/model"
+
+// Runs every check on the service, returning the errors found. The method
+// parameter models are generated along the way, since the later checks cover
+// them too.
+func Check(service *model.ServiceDefinition) []string {
+	errs := make([]string, 0)
+	CheckAnnotations(&errs, *service)
+	CheckDefaultValues(&errs, *service)
+	CheckConstraints(&errs, *service)
+	CheckErrors(&errs, *service)
+	CheckRoutes(&errs, *service)
+	GenerateMethodParameterModels(service)
+	CheckForDuplicateDeclarations(&errs, *service)
+	CheckTypeReferences(&errs, *service)
+	CheckMapKeyTypes(&errs, *service)
+	CheckForDuplicateModelFields(&errs, *service)
+	CheckForDuplicateEnumValues(&errs, *service)
+	CheckUnionVariants(&errs, *service)
+	return errs
+}
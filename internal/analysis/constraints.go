@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Makes sure that validation constraints are only put on fields and
+// parameters whose type they can check
+func CheckConstraints(errors *[]string, service model.ServiceDefinition) {
+	checkMisplaced(errors, service, "fields and parameters", model.Annotations.Constraints)
+
+	check := func(source model.Source, where string, ty model.Type, annotations model.Annotations) {
+		for _, constraint := range annotations.Constraints() {
+			if err := checkConstraintType(constraint, ty); err != nil {
+				msg := fmt.Sprintf("%s: @%s on %s %s", source, constraint.Name, where, err)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
+
+	for _, m := range service.Models {
+		for _, f := range m.Fields {
+			check(f.Source, fmt.Sprintf("field '%s' of model '%s'", f.Name, m.Name), f.Type, f.Annotations)
+		}
+	}
+
+	for _, m := range service.Methods {
+		for _, p := range m.Parameters {
+			check(p.Source, fmt.Sprintf("parameter '%s' of RPC '%s'", p.Name, m.Name), p.Type, p.Annotations)
+		}
+	}
+}
+
+func checkConstraintType(constraint model.Annotation, ty model.Type) error {
+	// optional values are only checked when present
+	if ty.Variant == model.TypeVariantOptional {
+		ty = *ty.Inner
+	}
+
+	switch constraint.Name {
+	case "minLength", "maxLength", "pattern":
+		if ty.Variant != model.TypeVariantNamed || ty.Name != "string" {
+			return fmt.Errorf("can only be used on strings, not '%s'", ty)
+		}
+	case "minItems", "maxItems":
+		if ty.Variant != model.TypeVariantArray {
+			return fmt.Errorf("can only be used on arrays, not '%s'", ty)
+		}
+	case "range":
+		if ty.Variant != model.TypeVariantNamed || (ty.Name != "int" && ty.Name != "float") {
+			return fmt.Errorf("can only be used on ints and floats, not '%s'", ty)
+		}
+		if ty.Name == "int" {
+			for _, argument := range constraint.Arguments {
+				if strings.ContainsAny(argument.Text, ".eE") {
+					return fmt.Errorf("expects integer bounds for an int, not %s", argument)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Makes sure that default values match the type of their field or parameter
+func CheckDefaultValues(errors *[]string, service model.ServiceDefinition) {
+	enums := make(map[string]model.Enum, len(service.Enums))
+	for _, e := range service.Enums {
+		enums[e.Name] = e
+	}
+
+	check := func(source model.Source, where string, ty model.Type, value *model.Value) {
+		if value == nil {
+			return
+		}
+
+		if err := checkDefaultValue(enums, ty, *value); err != nil {
+			msg := fmt.Sprintf("%s: default value %s of %s %s", source, value, where, err)
+			*errors = append(*errors, msg)
+		}
+	}
+
+	for _, m := range service.Models {
+		for _, f := range m.Fields {
+			check(f.Source, fmt.Sprintf("field '%s' of model '%s'", f.Name, m.Name), f.Type, f.Default)
+		}
+	}
+
+	for _, m := range service.Methods {
+		for _, p := range m.Parameters {
+			check(p.Source, fmt.Sprintf("parameter '%s' of RPC '%s'", p.Name, m.Name), p.Type, p.Default)
+		}
+	}
+}
+
+func checkDefaultValue(enums map[string]model.Enum, ty model.Type, value model.Value) error {
+	if ty.Variant == model.TypeVariantOptional {
+		return checkDefaultValue(enums, *ty.Inner, value)
+	}
+
+	if ty.Variant != model.TypeVariantNamed {
+		return errors.New("can't be used, only bool, int, float, string and enum types can have defaults")
+	}
+
+	mismatch := fmt.Errorf("doesn't match type '%s'", ty)
+	switch ty.Name {
+	case "bool":
+		if value.Kind != model.ValueKindIdentifier || (value.Text != "true" && value.Text != "false") {
+			return mismatch
+		}
+	case "int":
+		if value.Kind != model.ValueKindNumber {
+			return mismatch
+		}
+		if _, err := strconv.ParseInt(value.Text, 10, 64); err != nil {
+			return mismatch
+		}
+	case "float":
+		if value.Kind != model.ValueKindNumber {
+			return mismatch
+		}
+	case "string":
+		if value.Kind != model.ValueKindString {
+			return mismatch
+		}
+	default:
+		e, found := enums[ty.Name]
+		if !found {
+			return errors.New("can't be used, only bool, int, float, string and enum types can have defaults")
+		}
+
+		found = slices.ContainsFunc(e.Values, func(v model.EnumValue) bool {
+			return v.Name == value.Text
+		})
+		if value.Kind != model.ValueKindIdentifier || !found {
+			return fmt.Errorf("isn't a value of enum '%s'", e.Name)
+		}
+	}
+	return nil
+}
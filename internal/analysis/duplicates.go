@@ -8,17 +8,24 @@ import (
 )
 
 func CheckForDuplicateModelFields(errors *[]string, service model.ServiceDefinition) {
-	for _, m := range service.Models {
-		fieldNames := make([]string, 0, len(m.Fields))
-		for _, f := range m.Fields {
+	check := func(kind string, name string, fields []model.Field) {
+		fieldNames := make([]string, 0, len(fields))
+		for _, f := range fields {
 			if slices.Contains(fieldNames, f.Name) {
-				msg := fmt.Sprintf("duplicate field '%s' in model '%s'.", f.Name, m.Name)
+				msg := fmt.Sprintf("%s: duplicate field '%s' in %s '%s'.", f.Source, f.Name, kind, name)
 				*errors = append(*errors, msg)
 			} else {
 				fieldNames = append(fieldNames, f.Name)
 			}
 		}
 	}
+
+	for _, m := range service.Models {
+		check("model", m.Name, m.Fields)
+	}
+	for _, e := range service.Errors {
+		check("error", e.Name, e.Fields)
+	}
 }
 
 func CheckForDuplicateMethodParameters(errors *[]string, service *model.ServiceDefinition) {
@@ -26,9 +33,76 @@ func CheckForDuplicateMethodParameters(errors *[]string, service *model.ServiceD
 		names := make([]string, len(m.Parameters))
 		for _, param := range m.Parameters {
 			if slices.Contains(names, param.Name) {
-				msg := fmt.Sprintf("duplicate parameter \"%s\" in RPC \"%s\"", param.Name, m.Name)
+				msg := fmt.Sprintf("%s: duplicate parameter \"%s\" in RPC \"%s\"", param.Source, param.Name, m.Name)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
+}
+
+func CheckForDuplicateEnumValues(errors *[]string, service model.ServiceDefinition) {
+	for _, e := range service.Enums {
+		if len(e.Values) == 0 {
+			msg := fmt.Sprintf("%s: enum '%s' has no values.", e.Source, e.Name)
+			*errors = append(*errors, msg)
+		}
+
+		valueNames := make([]string, 0, len(e.Values))
+		for _, v := range e.Values {
+			if slices.Contains(valueNames, v.Name) {
+				msg := fmt.Sprintf("%s: duplicate value '%s' in enum '%s'.", v.Source, v.Name, e.Name)
 				*errors = append(*errors, msg)
+			} else {
+				valueNames = append(valueNames, v.Name)
 			}
 		}
 	}
 }
+
+// Makes sure that each type and RPC is only declared once across all of the
+// definition files
+func CheckForDuplicateDeclarations(errors *[]string, service model.ServiceDefinition) {
+	types := make(map[string]model.Source)
+	checkType := func(name string, source model.Source) {
+		if first, found := types[name]; found {
+			msg := fmt.Sprintf("%s: type '%s' is already declared at %s.", source, name, first)
+			*errors = append(*errors, msg)
+		} else {
+			types[name] = source
+		}
+	}
+
+	for _, m := range service.Models {
+		checkType(m.Name, m.Source)
+	}
+	for _, e := range service.Enums {
+		checkType(e.Name, e.Source)
+	}
+	for _, u := range service.Unions {
+		checkType(u.Name, u.Source)
+	}
+	for _, e := range service.Errors {
+		checkType(e.Name, e.Source)
+	}
+
+	services := make(map[string]model.Source)
+	for _, s := range service.Services {
+		if first, found := services[s.Name]; found {
+			msg := fmt.Sprintf("%s: service '%s' is already declared at %s.", s.Source, s.Name, first)
+			*errors = append(*errors, msg)
+		} else {
+			services[s.Name] = s.Source
+		}
+	}
+
+	methods := make(map[string]model.Source)
+	for _, m := range service.Methods {
+		key := fmt.Sprintf("%s.%s", m.Service, m.Name)
+		if first, found := methods[key]; found {
+			msg := fmt.Sprintf("%s: RPC '%s' is already declared at %s.", m.Source, m.Name, first)
+			*errors = append(*errors, msg)
+		} else {
+			methods[key] = m.Source
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Makes sure that RPCs only throw declared errors, and that @status is only
+// put on errors
+func CheckErrors(errors *[]string, service model.ServiceDefinition) {
+	checkMisplaced(errors, service, "errors", annotationNamed("status"), "error")
+
+	names := make([]string, 0, len(service.Errors))
+	for _, e := range service.Errors {
+		names = append(names, e.Name)
+
+		// errors are built by the server, so there's nothing to fill in or check
+		for _, f := range e.Fields {
+			if f.Default != nil {
+				msg := fmt.Sprintf("%s: field '%s' of error '%s' can't have a default value", f.Source, f.Name, e.Name)
+				*errors = append(*errors, msg)
+			}
+			for _, constraint := range f.Annotations.Constraints() {
+				msg := fmt.Sprintf("%s: @%s on field '%s' of error '%s' can only be used on fields of models and parameters", f.Source, constraint.Name, f.Name, e.Name)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
+
+	for _, m := range service.Methods {
+		thrown := make([]string, 0, len(m.Throws))
+		for _, name := range m.Throws {
+			if !slices.Contains(names, name) {
+				msg := fmt.Sprintf("%s: RPC '%s' throws undefined error '%s'", m.Source, m.Name, name)
+				*errors = append(*errors, msg)
+			} else if slices.Contains(thrown, name) {
+				msg := fmt.Sprintf("%s: RPC '%s' throws error '%s' more than once", m.Source, m.Name, name)
+				*errors = append(*errors, msg)
+			}
+			thrown = append(thrown, name)
+		}
+	}
+}
@@ -13,7 +13,8 @@ func GenerateMethodParameterModels(service *model.ServiceDefinition) {
 		}
 
 		paramsModel := model.Model{
-			Name: fmt.Sprintf("%sParams", method.Name),
+			Name:   fmt.Sprintf("%s%sParams", method.Service, method.Name),
+			Source: method.Source,
 		}
 		for _, param := range method.Parameters {
 			paramsModel.Fields = append(paramsModel.Fields, model.Field(param))
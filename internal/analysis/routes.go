@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Makes sure that @http is only put on RPCs which can be served at the route,
+// that the route's path variables are the RPC's parameters, and that no two
+// RPCs share a route
+func CheckRoutes(errors *[]string, service model.ServiceDefinition) {
+	checkMisplaced(errors, service, "RPCs", annotationNamed("http"), "RPC")
+
+	// path and query values are strings, so they can only hold scalars
+	const expected = "bool, int, float, string, uuid, date or enum"
+	scalars := []string{"bool", "int", "float", "string", "uuid", "date"}
+	for _, e := range service.Enums {
+		scalars = append(scalars, e.Name)
+	}
+	isScalar := func(ty model.Type) bool {
+		return ty.Variant == model.TypeVariantNamed && slices.Contains(scalars, ty.Name)
+	}
+
+	routes := make(map[string]string)
+	for _, m := range service.Methods {
+		route := m.Route()
+		annotation, found := m.Annotations.Find("http")
+		if found && annotationCheckers["http"](annotation.Arguments) != nil {
+			// already reported by CheckAnnotations
+			continue
+		}
+
+		// routes differing only in the names of their variables match the
+		// same requests
+		segments := strings.Split(route.Path, "/")
+		for idx, segment := range segments {
+			if _, found := model.PathVariable(segment); found {
+				segments[idx] = "{}"
+			}
+		}
+		key := fmt.Sprintf("%s %s", route.Method, strings.Join(segments, "/"))
+		if other, found := routes[key]; found {
+			msg := fmt.Sprintf("%s: RPC '%s' has the same route as RPC '%s' (%s %s)", m.Source, m.Name, other, route.Method, route.Path)
+			*errors = append(*errors, msg)
+		}
+		routes[key] = m.Name
+
+		if !m.HasRoute() {
+			continue
+		}
+
+		if m.Stream {
+			msg := fmt.Sprintf("%s: @http on RPC '%s' can't be used on stream RPCs", m.Source, m.Name)
+			*errors = append(*errors, msg)
+			continue
+		}
+
+		variables := route.Variables()
+		for idx, name := range variables {
+			if slices.Contains(variables[:idx], name) {
+				msg := fmt.Sprintf("%s: path variable '%s' of RPC '%s' is used more than once", m.Source, name, m.Name)
+				*errors = append(*errors, msg)
+				continue
+			}
+
+			p := slices.IndexFunc(m.Parameters, func(p model.MethodParameter) bool { return p.Name == name })
+			if p == -1 {
+				msg := fmt.Sprintf("%s: path variable '%s' of RPC '%s' isn't one of its parameters", m.Source, name, m.Name)
+				*errors = append(*errors, msg)
+			} else if ty := m.Parameters[p].Type; !isScalar(ty) {
+				msg := fmt.Sprintf("%s: path parameter '%s' of RPC '%s' must be a %s, not '%s'", m.Parameters[p].Source, name, m.Name, expected, ty)
+				*errors = append(*errors, msg)
+			}
+		}
+
+		for _, p := range m.QueryParameters() {
+			ty := p.Type
+			if ty.Variant == model.TypeVariantOptional || ty.Variant == model.TypeVariantArray {
+				ty = *ty.Inner
+			}
+			if !isScalar(ty) {
+				msg := fmt.Sprintf("%s: query parameter '%s' of RPC '%s' must be a %s, or an optional or array of one, not '%s'", p.Source, p.Name, m.Name, expected, p.Type)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
+}
@@ -9,12 +9,30 @@ import (
 
 // Makes sure that type references have a corresponding definition
 func CheckTypeReferences(errors *[]string, service model.ServiceDefinition) {
-	typeNames := getDefinedTypeNames(service.Models)
+	typeNames := getDefinedTypeNames(service)
 
 	for _, m := range service.Models {
 		for _, field := range m.Fields {
-			if !isTypeDefined(typeNames, field.Type) {
-				msg := fmt.Sprintf("undefined type '%s'", field.Type.Name)
+			if name, found := findUndefinedType(typeNames, field.Type); found {
+				msg := fmt.Sprintf("%s: undefined type '%s'", field.Source, name)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
+
+	for _, e := range service.Errors {
+		for _, field := range e.Fields {
+			if name, found := findUndefinedType(typeNames, field.Type); found {
+				msg := fmt.Sprintf("%s: undefined type '%s'", field.Source, name)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
+
+	for _, u := range service.Unions {
+		for _, variant := range u.Variants {
+			if name, found := findUndefinedType(typeNames, variant.Type); found {
+				msg := fmt.Sprintf("%s: undefined type '%s'", variant.Source, name)
 				*errors = append(*errors, msg)
 			}
 		}
@@ -22,38 +40,125 @@ func CheckTypeReferences(errors *[]string, service model.ServiceDefinition) {
 
 	for _, m := range service.Methods {
 		for _, p := range m.Parameters {
-			if !isTypeDefined(typeNames, p.Type) {
-				msg := fmt.Sprintf("undefined type '%s'", p.Type.Name)
+			if name, found := findUndefinedType(typeNames, p.Type); found {
+				msg := fmt.Sprintf("%s: undefined type '%s'", p.Source, name)
 				*errors = append(*errors, msg)
 			}
 		}
 
-		if m.ReturnType != nil && !isTypeDefined(typeNames, *m.ReturnType) {
-			msg := fmt.Sprintf("undefined type '%s'", m.ReturnType.Name)
-			*errors = append(*errors, msg)
+		if m.StreamParameter != nil {
+			if name, found := findUndefinedType(typeNames, *m.StreamParameter); found {
+				msg := fmt.Sprintf("%s: undefined type '%s'", m.Source, name)
+				*errors = append(*errors, msg)
+			}
+		}
+
+		if m.ReturnType != nil {
+			if name, found := findUndefinedType(typeNames, *m.ReturnType); found {
+				msg := fmt.Sprintf("%s: undefined type '%s'", m.Source, name)
+				*errors = append(*errors, msg)
+			}
 		}
 	}
 }
 
-func isTypeDefined(definedTypes []string, ty model.Type) bool {
-	for ty.Variant != model.TypeVariantNamed {
-		if ty.Inner != nil {
-			ty = *ty.Inner
-		} else {
-			panic("non-named types should have an inner type.")
+// returns the first name referenced by ty that isn't a defined type
+func findUndefinedType(definedTypes []string, ty model.Type) (string, bool) {
+	if ty.Variant == model.TypeVariantNamed {
+		return ty.Name, !slices.Contains(definedTypes, ty.Name)
+	}
+
+	if ty.Inner == nil {
+		panic("non-named types should have an inner type.")
+	}
+
+	if ty.Variant == model.TypeVariantMap {
+		if name, found := findUndefinedType(definedTypes, *ty.Key); found {
+			return name, found
 		}
 	}
 
-	return slices.Contains(definedTypes, ty.Name)
+	return findUndefinedType(definedTypes, *ty.Inner)
 }
 
-func getDefinedTypeNames(models []model.Model) []string {
-	names := make([]string, len(models))
+// Makes sure that map keys can be represented as JSON object keys
+func CheckMapKeyTypes(errors *[]string, service model.ServiceDefinition) {
+	keyTypes := []string{"string", "int", "uuid"}
+	for _, e := range service.Enums {
+		keyTypes = append(keyTypes, e.Name)
+	}
+
+	check := func(source model.Source, where string, ty model.Type) {
+		for _, key := range findMapKeys(ty) {
+			if key.Variant != model.TypeVariantNamed || !slices.Contains(keyTypes, key.Name) {
+				msg := fmt.Sprintf("%s: %s: map key type '%s' can't be used as a JSON object key", source, where, key)
+				*errors = append(*errors, msg)
+			}
+		}
+	}
 
-	for _, model := range models {
+	for _, m := range service.Models {
+		for _, field := range m.Fields {
+			check(field.Source, fmt.Sprintf("field '%s' of model '%s'", field.Name, m.Name), field.Type)
+		}
+	}
+
+	for _, e := range service.Errors {
+		for _, field := range e.Fields {
+			check(field.Source, fmt.Sprintf("field '%s' of error '%s'", field.Name, e.Name), field.Type)
+		}
+	}
+
+	for _, u := range service.Unions {
+		for _, variant := range u.Variants {
+			check(variant.Source, fmt.Sprintf("variant '%s' of union '%s'", variant.Name, u.Name), variant.Type)
+		}
+	}
+
+	for _, m := range service.Methods {
+		for _, p := range m.Parameters {
+			check(p.Source, fmt.Sprintf("parameter '%s' of RPC '%s'", p.Name, m.Name), p.Type)
+		}
+
+		if m.StreamParameter != nil {
+			check(m.Source, fmt.Sprintf("stream parameter of RPC '%s'", m.Name), *m.StreamParameter)
+		}
+
+		if m.ReturnType != nil {
+			check(m.Source, fmt.Sprintf("return type of RPC '%s'", m.Name), *m.ReturnType)
+		}
+	}
+}
+
+// returns the key types of every map within ty
+func findMapKeys(ty model.Type) []model.Type {
+	if ty.Variant == model.TypeVariantNamed {
+		return nil
+	}
+
+	keys := findMapKeys(*ty.Inner)
+	if ty.Variant == model.TypeVariantMap {
+		keys = append(keys, *ty.Key)
+		keys = append(keys, findMapKeys(*ty.Key)...)
+	}
+	return keys
+}
+
+func getDefinedTypeNames(service model.ServiceDefinition) []string {
+	names := make([]string, 0, len(service.Models)+len(service.Enums)+len(service.Unions))
+
+	for _, model := range service.Models {
 		names = append(names, model.Name)
 	}
 
+	for _, enum := range service.Enums {
+		names = append(names, enum.Name)
+	}
+
+	for _, union := range service.Unions {
+		names = append(names, union.Name)
+	}
+
 	names = append(names, "bool", "int", "string", "float", "uuid", "date")
 
 	return names
@@ -0,0 +1,64 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Makes sure that every union variant is a distinct model which doesn't
+// already have a field clashing with the union's discriminator.
+func CheckUnionVariants(errors *[]string, service model.ServiceDefinition) {
+	typeNames := getDefinedTypeNames(service)
+
+	for _, u := range service.Unions {
+		if len(u.Variants) == 0 {
+			msg := fmt.Sprintf("%s: union '%s' has no variants.", u.Source, u.Name)
+			*errors = append(*errors, msg)
+		}
+
+		tags := make([]string, 0, len(u.Variants))
+		types := make([]string, 0, len(u.Variants))
+		for _, variant := range u.Variants {
+			if slices.Contains(tags, variant.Name) {
+				msg := fmt.Sprintf("%s: duplicate variant '%s' in union '%s'.", variant.Source, variant.Name, u.Name)
+				*errors = append(*errors, msg)
+			} else {
+				tags = append(tags, variant.Name)
+			}
+
+			if variant.Type.Variant != model.TypeVariantNamed {
+				msg := fmt.Sprintf("%s: variant '%s' of union '%s' must be a model, but is '%s'.", variant.Source, variant.Name, u.Name, variant.Type)
+				*errors = append(*errors, msg)
+				continue
+			}
+
+			if slices.Contains(types, variant.Type.Name) {
+				msg := fmt.Sprintf("%s: model '%s' is used by more than one variant of union '%s'.", variant.Source, variant.Type.Name, u.Name)
+				*errors = append(*errors, msg)
+			} else {
+				types = append(types, variant.Type.Name)
+			}
+
+			idx := slices.IndexFunc(service.Models, func(m model.Model) bool {
+				return m.Name == variant.Type.Name
+			})
+			if idx < 0 {
+				// undefined types are reported by CheckTypeReferences
+				if slices.Contains(typeNames, variant.Type.Name) {
+					msg := fmt.Sprintf("%s: variant '%s' of union '%s' must be a model, but is '%s'.", variant.Source, variant.Name, u.Name, variant.Type)
+					*errors = append(*errors, msg)
+				}
+				continue
+			}
+
+			for _, f := range service.Models[idx].Fields {
+				if f.JSONName() == u.DiscriminatorJSONName() {
+					msg := fmt.Sprintf("%s: field '%s' of model '%s' clashes with the discriminator of union '%s'.", f.Source, f.Name, variant.Type.Name, u.Name)
+					*errors = append(*errors, msg)
+				}
+			}
+		}
+	}
+}
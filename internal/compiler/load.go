@@ -0,0 +1,161 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/fireland15/rpc-gen/internal/parser"
+)
+
+var ErrImportCycle = errors.New("import cycle")
+
+// Loads a definition file and everything it imports into a single service
+// definition. Imported declarations come before those of the importing file.
+type loader struct {
+	open    func(path string) (io.ReadCloser, error)
+	service model.ServiceDefinition
+	// absolute paths of the files merged into service
+	loaded []string
+	// the files currently being loaded, outermost first
+	loading []loadingFile
+}
+
+type loadingFile struct {
+	path    string
+	absPath string
+}
+
+func Load(definitionPath string) (model.ServiceDefinition, error) {
+	return LoadFrom(definitionPath, func(path string) (io.ReadCloser, error) {
+		return os.Open(path)
+	})
+}
+
+// Loads like Load, but reads the files with open, e.g. to load files which have
+// unsaved changes.
+func LoadFrom(definitionPath string, open func(path string) (io.ReadCloser, error)) (model.ServiceDefinition, error) {
+	l := &loader{open: open}
+	err := l.load(filepath.Clean(definitionPath))
+	return l.service, err
+}
+
+func (l *loader) load(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	for idx, loading := range l.loading {
+		if loading.absPath == absPath {
+			cycle := make([]string, 0)
+			for _, f := range l.loading[idx:] {
+				cycle = append(cycle, f.path)
+			}
+			cycle = append(cycle, path)
+			return fmt.Errorf("%w: %s", ErrImportCycle, strings.Join(cycle, " -> "))
+		}
+	}
+
+	if slices.Contains(l.loaded, absPath) {
+		return nil
+	}
+
+	l.loading = append(l.loading, loadingFile{path: path, absPath: absPath})
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+	}()
+
+	definitionFile, err := l.open(path)
+	if err != nil {
+		err = fmt.Errorf("problem opening definition file '%s': %w", path, err)
+		return err
+	}
+	defer definitionFile.Close()
+
+	p, err := parser.NewParser(definitionFile)
+	if err != nil {
+		err = fmt.Errorf("parsing error in '%s':\n%w", path, err)
+		return err
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		err = fmt.Errorf("parsing error in '%s':\n%w", path, err)
+		return err
+	}
+
+	setSourceFile(&def, path)
+
+	for _, imp := range def.Imports {
+		importPath := imp.Path
+		if !filepath.IsAbs(importPath) {
+			importPath = filepath.Join(filepath.Dir(path), importPath)
+		}
+
+		err = l.load(importPath)
+		if err != nil {
+			if errors.Is(err, ErrImportCycle) {
+				return err
+			}
+			return fmt.Errorf("%s: problem importing \"%s\": %w", imp.Source, imp.Path, err)
+		}
+	}
+
+	l.service.Models = append(l.service.Models, def.Models...)
+	l.service.Enums = append(l.service.Enums, def.Enums...)
+	l.service.Unions = append(l.service.Unions, def.Unions...)
+	l.service.Errors = append(l.service.Errors, def.Errors...)
+	l.service.Services = append(l.service.Services, def.Services...)
+	l.service.Methods = append(l.service.Methods, def.Methods...)
+	l.service.Imports = append(l.service.Imports, def.Imports...)
+	l.service.Files = append(l.service.Files, path)
+	l.loaded = append(l.loaded, absPath)
+	return nil
+}
+
+func setSourceFile(def *model.ServiceDefinition, path string) {
+	setFieldsFile := func(fields []model.Field) {
+		for idx := range fields {
+			fields[idx].Source.File = path
+		}
+	}
+
+	for idx := range def.Models {
+		def.Models[idx].Source.File = path
+		setFieldsFile(def.Models[idx].Fields)
+	}
+	for idx := range def.Enums {
+		def.Enums[idx].Source.File = path
+		for v := range def.Enums[idx].Values {
+			def.Enums[idx].Values[v].Source.File = path
+		}
+	}
+	for idx := range def.Unions {
+		def.Unions[idx].Source.File = path
+		for v := range def.Unions[idx].Variants {
+			def.Unions[idx].Variants[v].Source.File = path
+		}
+	}
+	for idx := range def.Errors {
+		def.Errors[idx].Source.File = path
+		setFieldsFile(def.Errors[idx].Fields)
+	}
+	for idx := range def.Services {
+		def.Services[idx].Source.File = path
+	}
+	for idx := range def.Methods {
+		def.Methods[idx].Source.File = path
+		for p := range def.Methods[idx].Parameters {
+			def.Methods[idx].Parameters[p].Source.File = path
+		}
+	}
+	for idx := range def.Imports {
+		def.Imports[idx].Source.File = path
+	}
+}
@@ -0,0 +1,66 @@
+package compiler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeDefinitions(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(source), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadMergesImports(t *testing.T) {
+	dir := writeDefinitions(t, map[string]string{
+		"main.rpc":          "import \"shared/common.rpc\"\nimport \"shared/status.rpc\"\nmodel Entry { status Status }",
+		"shared/common.rpc": "import \"status.rpc\"\nmodel Page { size int }",
+		"shared/status.rpc": "enum Status { Draft, Published }",
+	})
+
+	service, err := Load(filepath.Join(dir, "main.rpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(service.Models) != 2 || len(service.Enums) != 1 {
+		t.Fatalf("expected 2 models and 1 enum, got %d and %d", len(service.Models), len(service.Enums))
+	}
+
+	if service.Models[0].Name != "Page" || service.Models[1].Name != "Entry" {
+		t.Errorf("imported declarations should come first, got %s then %s", service.Models[0].Name, service.Models[1].Name)
+	}
+
+	expected := filepath.Join(dir, "shared", "status.rpc")
+	if service.Enums[0].Source.File != expected {
+		t.Errorf("expected enum source file to be %s, but was %s", expected, service.Enums[0].Source.File)
+	}
+
+	if len(service.Files) != 3 {
+		t.Errorf("expected 3 files, got %v", service.Files)
+	}
+}
+
+func TestLoadDetectsImportCycles(t *testing.T) {
+	dir := writeDefinitions(t, map[string]string{
+		"a.rpc":     "import \"dir/b.rpc\"",
+		"dir/b.rpc": "import \"../a.rpc\"",
+	})
+
+	_, err := Load(filepath.Join(dir, "a.rpc"))
+	if !errors.Is(err, ErrImportCycle) {
+		t.Errorf("expected an import cycle error, got %v", err)
+	}
+}
@@ -20,6 +20,9 @@ type RpcGenConfig struct {
 	RpcDefinitionFile string                     `json:"definition"`
 	Clients           map[string]json.RawMessage `json:"clients"`
 	Servers           map[string]json.RawMessage `json:"servers"`
+	// Documents describing the API, such as an OpenAPI document or JSON
+	// Schemas.
+	Docs map[string]json.RawMessage `json:"docs"`
 }
 
 func ReadConfig(file string) (*RpcGenConfig, error) {
@@ -0,0 +1,218 @@
+package generators
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A JSON object which keeps its keys in the order they were set, so that
+// generated documents read top to bottom.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]any)}
+}
+
+// sets key to value, keeping the key's position when it's already set
+func (o *object) set(key string, value any) *object {
+	if _, found := o.values[key]; !found {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+	return o
+}
+
+func (o *object) get(key string) (any, bool) {
+	value, found := o.values[key]
+	return value, found
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for idx, key := range o.keys {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// returns the object holding the keys of value in sorted order
+func sortedObject(value map[string]any) *object {
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	o := newObject()
+	for _, key := range keys {
+		o.set(key, value[key])
+	}
+	return o
+}
+
+// writes value to path as YAML when the path ends in .yaml or .yml, and as
+// indented JSON otherwise
+func writeDocument(path string, value any) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		sb := strings.Builder{}
+		err = writeYAML(&sb, value, "")
+		data = []byte(sb.String())
+	default:
+		data, err = json.MarshalIndent(value, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// writes a non-empty object or slice as a block of YAML, each line prefixed
+// with indent
+func writeYAML(sb *strings.Builder, value any, indent string) error {
+	value, err := yamlValue(value)
+	if err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case *object:
+		for _, key := range v.keys {
+			sb.WriteString(indent + yamlScalar(key) + ":")
+			err = writeYAMLChild(sb, v.values[key], indent)
+			if err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			// an item which is a block starts on the same line as its dash
+			child := strings.Builder{}
+			err = writeYAMLChild(&child, item, indent)
+			if err != nil {
+				return err
+			}
+
+			text := child.String()
+			if strings.HasPrefix(text, "\n"+indent+"  ") {
+				text = " " + strings.TrimPrefix(text, "\n"+indent+"  ")
+			}
+			sb.WriteString(indent + "-" + text)
+		}
+	default:
+		return fmt.Errorf("can't write %T as a block of YAML", value)
+	}
+	return nil
+}
+
+// writes the value of a key or item, which goes on the same line unless it's
+// a non-empty object or slice
+func writeYAMLChild(sb *strings.Builder, value any, indent string) error {
+	value, err := yamlValue(value)
+	if err != nil {
+		return err
+	}
+
+	if o, ok := value.(*object); ok && len(o.keys) > 0 {
+		sb.WriteString("\n")
+		return writeYAML(sb, value, indent+"  ")
+	}
+	if items, ok := value.([]any); ok && len(items) > 0 {
+		sb.WriteString("\n")
+		return writeYAML(sb, value, indent+"  ")
+	}
+
+	sb.WriteString(" " + yamlScalar(value) + "\n")
+	return nil
+}
+
+// returns value with its raw JSON decoded and its maps and slices made into
+// objects and []any, which are the only containers the YAML writer handles
+func yamlValue(value any) (any, error) {
+	switch v := value.(type) {
+	case json.RawMessage:
+		decoder := json.NewDecoder(bytes.NewReader(v))
+		decoder.UseNumber()
+		var decoded any
+		err := decoder.Decode(&decoded)
+		if err != nil {
+			return nil, err
+		}
+		return yamlValue(decoded)
+	case map[string]any:
+		return sortedObject(v), nil
+	case []string:
+		items := make([]any, len(v))
+		for idx, item := range v {
+			items[idx] = item
+		}
+		return items, nil
+	case []*object:
+		items := make([]any, len(v))
+		for idx, item := range v {
+			items[idx] = item
+		}
+		return items, nil
+	default:
+		return value, nil
+	}
+}
+
+// matches the strings which can be written without quotes
+var plainYAMLPattern = regexp.MustCompile(`^[A-Za-z_$/][A-Za-z0-9_$./{}-]*$`)
+
+// formats a scalar, or an empty object or slice, as YAML. Strings are only
+// quoted when they could be read as something else.
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case *object:
+		return "{}"
+	case []any:
+		return "[]"
+	case string:
+		reserved := []string{"true", "false", "null", "yes", "no", "on", "off", "y", "n"}
+		if plainYAMLPattern.MatchString(v) && !slices.Contains(reserved, strings.ToLower(v)) {
+			return v
+		}
+		quoted, _ := json.Marshal(v)
+		return string(quoted)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,61 @@
+package generators
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Writes the document as path, and returns what was written.
+func writeTestDocument(t *testing.T, name string, value any) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	err := writeDocument(path, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(written)
+}
+
+// A document with the strings YAML would read as something else unless
+// they're quoted, and blocks nested in each other.
+func yamlTestDocument() *object {
+	responses := newObject().
+		set("200", newObject().set("description", "OK")).
+		set("404", newObject().set("description", "Not found: the entry doesn't exist"))
+
+	parameters := []*object{
+		newObject().set("name", "id").set("in", "path").set("required", true),
+		newObject().set("name", "tags").set("in", "query").set("schema", newObject().
+			set("type", "array").
+			set("items", newObject().set("enum", []any{"yes", "no", "On", "null", "1.0"}))),
+	}
+
+	return newObject().
+		set("openapi", "3.1.0").
+		set("info", newObject().set("title", "journal").set("version", "1.0.0")).
+		set("description", "The first line.\nThe second line.").
+		set("path", "/entries/{id}").
+		set("parameters", parameters).
+		set("allOf", []any{newObject().set("$ref", "#/components/schemas/Entry"), newObject()}).
+		set("required", []string{}).
+		set("responses", responses).
+		set("default", json.RawMessage(`{"limit": 20, "tags": ["a", "b"], "filter": null}`)).
+		set("values", []any{[]any{1, 2}, "-", ""})
+}
+
+func TestWriteDocumentWritesYAML(t *testing.T) {
+	written := writeTestDocument(t, "openapi.yaml", yamlTestDocument())
+	expectGolden(t, "document.yaml.golden", written)
+}
+
+func TestWriteDocumentWritesJSON(t *testing.T) {
+	written := writeTestDocument(t, "openapi.json", yamlTestDocument())
+	expectGolden(t, "document.json.golden", written)
+}
@@ -2,7 +2,9 @@ package generators
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"slices"
 
 	"github.com/fireland15/rpc-gen/internal/config"
 	"github.com/fireland15/rpc-gen/internal/model"
@@ -32,6 +34,18 @@ func GeneratorFromConfig(config *config.RpcGenConfig) (CodeGenerator, error) {
 				return nil, err
 			}
 			generator.inner = append(generator.inner, gen)
+		} else if client == "python" {
+			gen, err := NewPythonClientGenerator(clientConfig)
+			if err != nil {
+				return nil, err
+			}
+			generator.inner = append(generator.inner, gen)
+		} else if client == "go" {
+			gen, err := NewGoClientGenerator(clientConfig)
+			if err != nil {
+				return nil, err
+			}
+			generator.inner = append(generator.inner, gen)
 		}
 	}
 
@@ -43,6 +57,41 @@ func GeneratorFromConfig(config *config.RpcGenConfig) (CodeGenerator, error) {
 				return nil, err
 			}
 			generator.inner = append(generator.inner, gen)
+		} else if server == "go-http" {
+			gen, err := NewGoHttpServerGenerator(serverConfig)
+			if err != nil {
+				return nil, err
+			}
+			generator.inner = append(generator.inner, gen)
+		} else if server == "go-chi" {
+			gen, err := NewGoChiServerGenerator(serverConfig)
+			if err != nil {
+				return nil, err
+			}
+			generator.inner = append(generator.inner, gen)
+		} else if server == "typescript-express" {
+			gen, err := NewTypescriptExpressServerGenerator(serverConfig)
+			if err != nil {
+				return nil, err
+			}
+			generator.inner = append(generator.inner, gen)
+		}
+	}
+
+	for doc, docConfig := range config.Docs {
+		log.Printf("Configuring %s document generator.\n", doc)
+		if doc == "openapi" {
+			gen, err := NewOpenAPIGenerator(docConfig)
+			if err != nil {
+				return nil, err
+			}
+			generator.inner = append(generator.inner, gen)
+		} else if doc == "jsonschema" {
+			gen, err := NewJSONSchemaGenerator(docConfig)
+			if err != nil {
+				return nil, err
+			}
+			generator.inner = append(generator.inner, gen)
 		}
 	}
 
@@ -58,3 +107,137 @@ func (g *rootGenerator) Generate(service *model.ServiceDefinition) error {
 	}
 	return nil
 }
+
+// The methods of a single service. The unnamed service holds the RPCs declared
+// outside of any service block.
+type serviceGroup struct {
+	model.Service
+	Methods []model.Method
+}
+
+// Groups the methods of the definition by the service they were declared in.
+// The unnamed service comes first when it has any methods.
+func groupServices(service *model.ServiceDefinition) []serviceGroup {
+	groups := make([]serviceGroup, 0, len(service.Services)+1)
+
+	if methods := service.MethodsOf(""); len(methods) > 0 {
+		groups = append(groups, serviceGroup{Methods: methods})
+	}
+
+	for _, s := range service.Services {
+		groups = append(groups, serviceGroup{
+			Service: s,
+			Methods: service.MethodsOf(s.Name),
+		})
+	}
+
+	return groups
+}
+
+// Returns the names of the models which need validating, either because their
+// fields have constraints or because they hold models which do.
+func validatedModels(service *model.ServiceDefinition) map[string]bool {
+	validated := make(map[string]bool)
+	for _, m := range service.Models {
+		for _, f := range m.Fields {
+			if len(f.Annotations.Constraints()) > 0 {
+				validated[m.Name] = true
+			}
+		}
+	}
+
+	// keep marking the models holding validated models until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for _, m := range service.Models {
+			if validated[m.Name] {
+				continue
+			}
+			for _, f := range m.Fields {
+				if validated[elementType(f.Type).Name] {
+					validated[m.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	return validated
+}
+
+// returns the type held by optionals and arrays
+func elementType(ty model.Type) model.Type {
+	for ty.Variant == model.TypeVariantOptional || ty.Variant == model.TypeVariantArray {
+		ty = *ty.Inner
+	}
+	return ty
+}
+
+// The protocols of the generated code. By default each RPC is a POST to its own
+// path, but it can instead be a JSON-RPC 2.0 call to a single endpoint.
+const (
+	protocolHTTP    = "http"
+	protocolJSONRPC = "jsonrpc"
+)
+
+func checkProtocol(protocol string) error {
+	if protocol != "" && protocol != protocolHTTP && protocol != protocolJSONRPC {
+		return fmt.Errorf("unknown protocol '%s', expected '%s' or '%s'", protocol, protocolHTTP, protocolJSONRPC)
+	}
+	return nil
+}
+
+// Returns true when any of the service's methods streams its return values
+// as Server-Sent Events.
+func hasStreams(service *model.ServiceDefinition) bool {
+	return slices.ContainsFunc(service.Methods, func(m model.Method) bool {
+		return m.Stream && !m.Bidirectional()
+	})
+}
+
+// Returns true when any of the service's methods streams in both directions
+// over a WebSocket.
+func hasBidirectionalStreams(service *model.ServiceDefinition) bool {
+	return slices.ContainsFunc(service.Methods, model.Method.Bidirectional)
+}
+
+// Returns an error naming the first bidirectional method of the service, for
+// the generators which don't support them.
+func checkBidirectionalStreams(service *model.ServiceDefinition, generator string) error {
+	for _, m := range service.Methods {
+		if m.Bidirectional() {
+			return fmt.Errorf("bidirectional RPC '%s' isn't supported (%s)", m.Name, generator)
+		}
+	}
+	return nil
+}
+
+// Returns an error naming the first stream method of the service, for the
+// generators using the jsonrpc protocol.
+func checkJSONRPCStreams(service *model.ServiceDefinition, generator string) error {
+	for _, m := range service.Methods {
+		if m.Stream {
+			return fmt.Errorf("stream RPC '%s' isn't supported by the jsonrpc protocol (%s)", m.Name, generator)
+		}
+	}
+	return nil
+}
+
+// Returns true when any of the service's methods has an @http route.
+func hasRoutes(service *model.ServiceDefinition) bool {
+	return slices.ContainsFunc(service.Methods, model.Method.HasRoute)
+}
+
+// Returns true when any of the method's parameters are sent in the path or
+// query string of its route.
+func isBound(m model.Method) bool {
+	return len(m.Route().Variables()) > 0 || len(m.QueryParameters()) > 0
+}
+
+// Returns whether a parameter bound from the path or query string is a string
+// in JSON. Bound numbers and bools are JSON as they are.
+func isQuoted(p model.MethodParameter) bool {
+	name := elementType(p.Type).Name
+	return name != "bool" && name != "int" && name != "float"
+}
@@ -0,0 +1,106 @@
+package generators
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fireland15/rpc-gen/internal/analysis"
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/fireland15/rpc-gen/internal/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the generated output")
+
+// Parses and checks a definition, failing the test when it has errors.
+func definition(t *testing.T, source string) *model.ServiceDefinition {
+	t.Helper()
+	p, err := parser.NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := analysis.Check(&service)
+	if len(errs) > 0 {
+		t.Fatalf("definition errors:\n%s", strings.Join(errs, "\n"))
+	}
+	return &service
+}
+
+// Runs the generator made from the config on the definition, and returns what
+// it wrote. The output is written to a temporary file named name.
+func generate(t *testing.T, newGenerator func(json.RawMessage) (CodeGenerator, error), config map[string]any, name string, source string) string {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), name)
+	config["output"] = output
+	raw, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := newGenerator(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = g.Generate(definition(t, source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(generated)
+}
+
+// Compares the generated output with testdata/name, or rewrites it when the
+// tests are run with -update.
+func expectGolden(t *testing.T, name string, generated string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		err := os.MkdirAll("testdata", os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(generated), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s (run the tests with -update to create it)", err)
+	}
+	if string(golden) == generated {
+		return
+	}
+
+	expected := strings.Split(string(golden), "\n")
+	actual := strings.Split(generated, "\n")
+	for idx := 0; idx < max(len(expected), len(actual)); idx++ {
+		want, got := "<end of file>", "<end of file>"
+		if idx < len(expected) {
+			want = expected[idx]
+		}
+		if idx < len(actual) {
+			got = actual[idx]
+		}
+		if want != got {
+			t.Errorf("generated output doesn't match %s at line %d:\nexpected: %s\n     got: %s", path, idx+1, want, got)
+			return
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package generators
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/iancoleman/strcase"
+)
+
+// Generates a Go client for each service. It's configured the same way as the
+// Go servers, and its types are identical to theirs.
+type GoClientGenerator struct {
+	*goGenerator
+	// whether any of the definition's methods has an @http route
+	routes bool
+}
+
+//go:embed go_client.tmpl
+var go_client_template string
+
+func NewGoClientGenerator(config json.RawMessage) (CodeGenerator, error) {
+	// the generator isn't made until the templates are parsed, but hasRoutes
+	// needs it
+	var c *GoClientGenerator
+
+	funcs := make(template.FuncMap, 0)
+	funcs["clientName"] = func(service string) string {
+		return fmt.Sprintf("%sClient", strcase.ToCamel(service))
+	}
+	funcs["deref"] = func(ty *model.Type) model.Type {
+		return *ty
+	}
+	funcs["hasRoutes"] = func() bool {
+		return c.routes
+	}
+	funcs["httpMethod"] = func(m model.Method) string {
+		return fmt.Sprintf("http.Method%s", strcase.ToCamel(strings.ToLower(m.Route().Method)))
+	}
+	funcs["isBound"] = isBound
+	funcs["pathVariables"] = func(m model.Method) string {
+		variables := make([]string, 0)
+		for _, name := range m.Route().Variables() {
+			variables = append(variables, fmt.Sprintf("%q: %s", name, name))
+		}
+		return valuesMap(variables)
+	}
+	funcs["queryValues"] = func(m model.Method) string {
+		values := make([]string, 0)
+		for _, p := range m.QueryParameters() {
+			values = append(values, fmt.Sprintf("%q: %s", p.JSONName(), p.Name))
+		}
+		return valuesMap(values)
+	}
+
+	g, err := newGoGenerator(config, "go-client", funcs, go_client_template)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &GoClientGenerator{goGenerator: g}
+	return c, nil
+}
+
+// formats the entries as a map[string]any, or nil when there are none
+func valuesMap(entries []string) string {
+	if len(entries) == 0 {
+		return "nil"
+	}
+	return fmt.Sprintf("map[string]any{%s}", strings.Join(entries, ", "))
+}
+
+func (g *GoClientGenerator) Generate(service *model.ServiceDefinition) error {
+	err := checkBidirectionalStreams(service, "GoClientGenerator")
+	if err != nil {
+		return err
+	}
+
+	if g.config.Protocol == protocolJSONRPC {
+		return fmt.Errorf("the jsonrpc protocol isn't supported (GoClientGenerator)")
+	}
+
+	err = os.MkdirAll(filepath.Dir(g.config.Output), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(g.config.Output)
+	if err != nil {
+		err = fmt.Errorf("problem opening '%s' (GoClientGenerator): %w", g.config.Output, err)
+		return err
+	}
+	defer f.Close()
+
+	imports := []string{"bytes", "context", "encoding/json", "fmt", "io", "net/http", "strings"}
+	if hasStreams(service) {
+		imports = append(imports, "bufio")
+	}
+	g.routes = hasRoutes(service)
+	if g.routes {
+		imports = append(imports, "net/url")
+	}
+
+	err = g.writeHeader(f, service, imports...)
+	if err != nil {
+		return err
+	}
+
+	err = g.writeTypes(f, service)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groupServices(service) {
+		err = g.template.ExecuteTemplate(f, "service_interface", group)
+		if err != nil {
+			return err
+		}
+
+		err = g.template.ExecuteTemplate(f, "client", group)
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.template.ExecuteTemplate(f, "client_helpers", service.Errors)
+}
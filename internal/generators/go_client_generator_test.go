@@ -0,0 +1,121 @@
+package generators
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoClientCallsServices(t *testing.T) {
+	source := `
+model Entry {
+    title string
+    tags  string[]
+    note  string?
+}
+
+@status(404)
+error NotFound {
+    id int
+}
+
+service Journal {
+    rpc GetEntry(id int) Entry throws NotFound
+    rpc SaveEntry(entry Entry)
+}
+`
+	generated := generate(t, NewGoClientGenerator, map[string]any{"package": "client"}, "client.go", source)
+	expectGolden(t, "go_client.golden", generated)
+}
+
+const routesDefinition = `
+enum Mood {
+    Happy,
+    Sad,
+}
+
+model Entry {
+    title string
+}
+
+service Journal {
+    @http(GET, "/entries/{id}")
+    rpc GetEntry(id string, verbose bool?, tags string[], mood Mood? @json("entry_mood")) Entry
+    @http(PUT, "/entries/{id}")
+    rpc UpdateEntry(id string, entry Entry) Entry
+    @http(DELETE, "/entries/{id}")
+    rpc DeleteEntry(id string)
+}
+`
+
+func TestGoClientCallsRoutes(t *testing.T) {
+	generated := generate(t, NewGoClientGenerator, map[string]any{"package": "client"}, "client.go", routesDefinition)
+	expectGolden(t, "go_client_routes.golden", generated)
+}
+
+const routesServerMain = `package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"roundtrip/client"
+	"roundtrip/server"
+)
+
+type service struct{}
+
+func (service) GetEntry(id string, verbose *bool, tags []string, mood *server.Mood) (server.Entry, error) {
+	return server.Entry{Title: fmt.Sprintf("get %s %v %v %v", id, *verbose, tags, *mood)}, nil
+}
+
+func (service) UpdateEntry(id string, entry server.Entry) (server.Entry, error) {
+	return server.Entry{Title: fmt.Sprintf("update %s %s", id, entry.Title)}, nil
+}
+
+func (service) DeleteEntry(id string) error {
+	fmt.Printf("delete %s\n", id)
+	return nil
+}
+
+func main() {
+	mux := http.NewServeMux()
+	server.NewJournalHandler(service{}).RegisterHandlers(mux)
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	c := client.NewJournalClient(s.URL, s.Client())
+	verbose, mood := true, client.MoodSad
+	entry, err := c.GetEntry("a/b c", &verbose, []string{"x", "y"}, &mood)
+	fmt.Println(entry.Title, err)
+	entry, err = c.UpdateEntry("a/b c", client.Entry{Title: "new"})
+	fmt.Println(entry.Title, err)
+	fmt.Println(c.DeleteEntry("a/b c"))
+}
+`
+
+// The client sends each parameter of an @http route where the Go servers bind
+// it from.
+func TestGoClientCallsGoServerRoutes(t *testing.T) {
+	dir := t.TempDir()
+	for _, pkg := range []string{"client", "server"} {
+		err := os.Mkdir(filepath.Join(dir, pkg), os.ModePerm)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "server"}, "server.go", routesDefinition)
+	writeFile(t, filepath.Join(dir, "server", "server.go"), generated)
+	generated = generate(t, NewGoClientGenerator, map[string]any{"package": "client"}, "client.go", routesDefinition)
+	writeFile(t, filepath.Join(dir, "client", "client.go"), generated)
+	writeFile(t, filepath.Join(dir, "main.go"), routesServerMain)
+	writeFile(t, filepath.Join(dir, "go.mod"), "module roundtrip\n\ngo 1.22\n")
+	output := run(t, dir, "go", "run", ".")
+
+	expected := "get a/b c true [x y] Sad <nil>\nupdate a/b c new <nil>\ndelete a/b c\n<nil>"
+	if output != expected {
+		t.Errorf("expected the routes to be called with their parameters:\n%s\nbut got:\n%s", expected, output)
+	}
+}
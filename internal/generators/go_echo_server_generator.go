@@ -6,104 +6,55 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"slices"
 	"strings"
 	"text/template"
 
 	"github.com/fireland15/rpc-gen/internal/model"
-	"github.com/iancoleman/strcase"
 )
 
-type GoServerConfig struct {
-	Output  string `json:"output"`
-	Package string `json:"package"`
-	Types   map[string]struct {
-		Package   string `json:"package"`
-		Namespace string `json:"namespace"`
-		TypeName  string `json:"typeName"`
-	} `json:"types"`
-}
-
 type GoEchoServerGenerator struct {
-	config   GoServerConfig
-	template *template.Template
+	*goGenerator
 }
 
 //go:embed go_echo_server.tmpl
 var go_server_template string
 
 func NewGoEchoServerGenerator(config json.RawMessage) (CodeGenerator, error) {
-	if config == nil {
-		panic("config is nil")
-	}
-
-	c := new(GoEchoServerGenerator)
-
-	err := json.Unmarshal(config, &c.config)
-	if err != nil {
-		return nil, err
-	}
-
-	funcs := make(template.FuncMap, 0)
-	funcs["toCamel"] = strcase.ToCamel
-	funcs["toLowerCamel"] = strcase.ToLowerCamel
-	funcs["toSnake"] = strcase.ToSnake
-	funcs["toSignature"] = func(m model.Method) string {
-		params := make([]string, len(m.Parameters))
-		for idx, p := range m.Parameters {
-			params[idx] = fmt.Sprintf("%s %s", p.Name, c.resolveType(p.Type))
-		}
-
-		returnType := "error"
-		if m.ReturnType != nil {
-			returnType = fmt.Sprintf("(%s, error)", c.resolveType(*m.ReturnType))
-		}
-
-		return fmt.Sprintf("%s(%s) %s", m.Name, strings.Join(params, ", "), returnType)
-	}
-	funcs["joinParameters"] = func(m model.Method) string {
-		params := make([]string, len(m.Parameters))
-		for idx, p := range m.Parameters {
-			params[idx] = fmt.Sprintf("params.%s", strcase.ToCamel(p.Name))
-		}
-		return strings.Join(params, ", ")
-	}
-	funcs["hasParameters"] = func(m model.Method) bool {
-		return len(m.Parameters) > 0
-	}
-	funcs["hasReturnValue"] = func(m model.Method) bool {
-		return m.ReturnType != nil
-	}
-	funcs["resolveType"] = c.resolveType
-
-	tmpl, err := template.New("go-echo").Funcs(funcs).Parse(go_server_template)
+	funcs := template.FuncMap{
+		"request": func() string {
+			return "c.Request()"
+		},
+		"pathValue": func(name string) string {
+			return fmt.Sprintf("c.Param(%q)", name)
+		},
+		// echo writes path variables as :name rather than {name}
+		"echoPath": func(path string) string {
+			segments := strings.Split(path, "/")
+			for idx, segment := range segments {
+				if name, found := model.PathVariable(segment); found {
+					segments[idx] = ":" + name
+				}
+			}
+			return strings.Join(segments, "/")
+		},
+	}
+
+	g, err := newGoGenerator(config, "go-echo", funcs, go_server_template, go_jsonrpc_server_template)
 	if err != nil {
 		return nil, err
 	}
 
-	c.template = tmpl
-	return c, nil
+	return &GoEchoServerGenerator{g}, nil
 }
 
-func (g *GoEchoServerGenerator) resolveType(typeName model.Type) string {
-	if typeName.Variant == model.TypeVariantNamed {
-		typeConfig, found := g.config.Types[typeName.Name]
-		if !found {
-			return typeName.Name
+func (g *GoEchoServerGenerator) Generate(service *model.ServiceDefinition) error {
+	if g.config.Protocol == protocolJSONRPC {
+		err := checkJSONRPCStreams(service, "GoEchoServerGenerator")
+		if err != nil {
+			return err
 		}
-		return fmt.Sprintf("%s.%s", typeConfig.Namespace, typeConfig.TypeName)
-	} else if typeName.Variant == model.TypeVariantOptional {
-		inner := g.resolveType(*typeName.Inner)
-		return fmt.Sprintf("*%s", inner)
-	} else if typeName.Variant == model.TypeVariantArray {
-		inner := g.resolveType(*typeName.Inner)
-		return fmt.Sprintf("[]%s", inner)
-	} else {
-		panic("unreachable")
 	}
-}
 
-func (g *GoEchoServerGenerator) Generate(service *model.ServiceDefinition) error {
 	err := os.MkdirAll(filepath.Dir(g.config.Output), os.ModePerm)
 	if err != nil {
 		return err
@@ -116,55 +67,98 @@ func (g *GoEchoServerGenerator) Generate(service *model.ServiceDefinition) error
 	}
 	defer f.Close()
 
-	_, err = fmt.Fprintln(f, "// This file is autogenerated. Any changes will be overwritten when regenerated.")
+	if g.config.Protocol == protocolJSONRPC {
+		return g.writeJSONRPCServer(f, service)
+	}
+
+	imports := []string{"net/http", "github.com/labstack/echo/v4"}
+	if len(service.Errors) > 0 {
+		imports = append(imports, "errors", "slices")
+	}
+	if hasStreams(service) {
+		imports = append(imports, "encoding/json", "fmt")
+	}
+	if hasBidirectionalStreams(service) {
+		imports = append(imports, "encoding/json", "errors", "io", "sync", "time", "github.com/gorilla/websocket")
+	}
+	if hasRoutes(service) {
+		imports = append(imports, "encoding/json", "errors", "io", "net/url")
+	}
+
+	err = g.writeHeader(f, service, imports...)
 	if err != nil {
 		return err
 	}
 
-	_, err = fmt.Fprintf(f, "package %s\n\n", g.config.Package)
+	err = g.writeTypes(f, service)
 	if err != nil {
 		return err
 	}
 
-	imports := make([]string, 0)
-	for _, t := range g.config.Types {
-		if !slices.Contains(imports, t.Package) {
-			imports = append(imports, t.Package)
+	if g.config.Context {
+		err = g.template.ExecuteTemplate(f, "context_helpers", nil)
+		if err != nil {
+			return err
 		}
 	}
 
-	err = g.template.ExecuteTemplate(f, "imports", imports)
-	if err != nil {
-		return err
+	if len(service.Errors) > 0 {
+		err = g.template.ExecuteTemplate(f, "error_response_helper", nil)
+		if err != nil {
+			return err
+		}
 	}
 
-	for _, m := range service.Models {
-		err = g.template.ExecuteTemplate(f, "model", m)
+	if hasStreams(service) {
+		err = g.template.ExecuteTemplate(f, "event_stream_helpers", nil)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = g.template.ExecuteTemplate(f, "service_interface", service)
-	if err != nil {
-		return err
+	if hasBidirectionalStreams(service) {
+		err = g.template.ExecuteTemplate(f, "websocket_helpers", nil)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = g.template.ExecuteTemplate(f, "handler", service)
-	if err != nil {
-		return err
+	if hasRoutes(service) {
+		err = g.template.ExecuteTemplate(f, "route_helpers", nil)
+		if err != nil {
+			return err
+		}
 	}
 
-	for _, m := range service.Methods {
-		err = g.template.ExecuteTemplate(f, "handler_func", m)
+	for _, group := range groupServices(service) {
+		err = g.template.ExecuteTemplate(f, "service_interface", group)
 		if err != nil {
 			return err
 		}
-	}
 
-	err = g.template.ExecuteTemplate(f, "register_handler", service)
-	if err != nil {
-		return err
+		err = g.template.ExecuteTemplate(f, "handler", group)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range group.Methods {
+			handler := "handler_func"
+			if m.Bidirectional() {
+				handler = "bidi_handler_func"
+			} else if m.Stream {
+				handler = "stream_handler_func"
+			}
+
+			err = g.template.ExecuteTemplate(f, handler, m)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = g.template.ExecuteTemplate(f, "register_handler", group)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
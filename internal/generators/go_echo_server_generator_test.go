@@ -0,0 +1,22 @@
+package generators
+
+import "testing"
+
+func TestGoEchoServerValidatesConstraints(t *testing.T) {
+	source := `
+model Tag {
+    name string @minLength(1) @pattern("^[a-z]+$")
+}
+
+model CreateEntryRequest {
+    title  string @minLength(3) @maxLength(64)
+    rating int @range(1, 5)
+    tags   Tag[] @maxItems(10)
+    note   string? @maxLength(200)
+}
+
+rpc CreateEntry(request CreateEntryRequest, score int @range(0, 100)) int
+`
+	generated := generate(t, NewGoEchoServerGenerator, map[string]any{"package": "server"}, "server.go", source)
+	expectGolden(t, "go_echo_server_constraints.golden", generated)
+}
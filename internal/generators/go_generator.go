@@ -0,0 +1,527 @@
+package generators
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/iancoleman/strcase"
+)
+
+type GoServerConfig struct {
+	Output  string `json:"output"`
+	Package string `json:"package"`
+	// Passes a context.Context to every service method as its first argument.
+	Context bool `json:"context"`
+	// Either "http", the default, or "jsonrpc" for a single JSON-RPC 2.0
+	// endpoint.
+	Protocol string `json:"protocol"`
+	Types    map[string]struct {
+		Package   string `json:"package"`
+		Namespace string `json:"namespace"`
+		TypeName  string `json:"typeName"`
+	} `json:"types"`
+}
+
+// What the Go generators have in common: their config, the templates for the
+// definition's types and the funcs those templates use.
+type goGenerator struct {
+	config   GoServerConfig
+	template *template.Template
+	// the models with a Validate method
+	validated map[string]bool
+	// whether the definition declares any errors
+	hasErrors bool
+	// whether any of the definition's methods stream
+	streams bool
+}
+
+//go:embed go_common.tmpl
+var go_common_template string
+
+// parses the config and the common templates along with the sources, which can
+// use the extra funcs on top of the common ones
+func newGoGenerator(config json.RawMessage, name string, extra template.FuncMap, sources ...string) (*goGenerator, error) {
+	if config == nil {
+		panic("config is nil")
+	}
+
+	c := new(goGenerator)
+
+	err := json.Unmarshal(config, &c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkProtocol(c.config.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := make(template.FuncMap, 0)
+	funcs["toCamel"] = strcase.ToCamel
+	funcs["toLowerCamel"] = strcase.ToLowerCamel
+	funcs["toSnake"] = strcase.ToSnake
+	funcs["toSignature"] = func(m model.Method) string {
+		params := make([]string, 0, len(m.Parameters)+1)
+		if c.config.Context {
+			params = append(params, "ctx context.Context")
+		}
+		for _, p := range m.Parameters {
+			params = append(params, fmt.Sprintf("%s %s", p.Name, c.resolveType(p.Type)))
+		}
+
+		returnType := "error"
+		if m.Bidirectional() {
+			params = append(params, fmt.Sprintf("stream BidiStream[%s]", c.streamTypes(m)))
+		} else if m.Stream {
+			params = append(params, fmt.Sprintf("send func(%s) error", c.resolveType(*m.ReturnType)))
+		} else if m.ReturnType != nil {
+			returnType = fmt.Sprintf("(%s, error)", c.resolveType(*m.ReturnType))
+		}
+
+		return fmt.Sprintf("%s(%s) %s", m.Name, strings.Join(params, ", "), returnType)
+	}
+	funcs["usesContext"] = func() bool {
+		return c.config.Context
+	}
+	funcs["hasErrors"] = func() bool {
+		return c.hasErrors
+	}
+	funcs["hasStreams"] = func() bool {
+		return c.streams
+	}
+	funcs["streamTypes"] = c.streamTypes
+	funcs["throwsArguments"] = func(m model.Method) string {
+		arguments := ""
+		for _, name := range m.Throws {
+			arguments += fmt.Sprintf(", %q", name)
+		}
+		return arguments
+	}
+	funcs["errorMessage"] = func(name string) string {
+		return strcase.ToDelimited(name, ' ')
+	}
+	funcs["hasParameters"] = func(m model.Method) bool {
+		return len(m.Parameters) > 0
+	}
+	funcs["hasReturnValue"] = func(m model.Method) bool {
+		return m.ReturnType != nil
+	}
+	funcs["goDefault"] = goDefault
+	funcs["isValidated"] = func(name string) bool {
+		return c.validated[name]
+	}
+	funcs["patternName"] = patternName
+	funcs["violationCondition"] = violationCondition
+	funcs["violationMessage"] = violationMessage
+	funcs["validateNested"] = func(f model.Field) string {
+		value := fmt.Sprintf("m.%s", strcase.ToCamel(f.Name))
+		path := fmt.Sprintf("path + %q", f.JSONName())
+		return c.validateNested(value, path, f.Type, "    ", 0)
+	}
+	funcs["resolveType"] = c.resolveType
+	funcs["goDoc"] = goDoc
+	funcs["deprecated"] = func(doc string, annotations model.Annotations) string {
+		annotation, found := annotations.Find("deprecated")
+		if !found {
+			return doc
+		}
+
+		reason := "no longer supported."
+		if len(annotation.Arguments) > 0 {
+			reason = annotation.Arguments[0].Text
+		}
+
+		if doc == "" {
+			return fmt.Sprintf("Deprecated: %s", reason)
+		}
+		return fmt.Sprintf("%s\n\nDeprecated: %s", doc, reason)
+	}
+	funcs["serviceInterfaceName"] = func(service string) string {
+		return fmt.Sprintf("%sService", strcase.ToCamel(service))
+	}
+	funcs["handlerName"] = func(service string) string {
+		return fmt.Sprintf("%sHandler", strcase.ToCamel(service))
+	}
+	funcs["newParams"] = func(m model.Method) string {
+		for _, p := range m.Parameters {
+			if p.Default != nil {
+				return fmt.Sprintf("New%s()", strcase.ToCamel(m.ParameterType.Name))
+			}
+		}
+		return fmt.Sprintf("%s{}", strcase.ToCamel(m.ParameterType.Name))
+	}
+	funcs["joinParameters"] = func(m model.Method) string {
+		params := make([]string, 0, len(m.Parameters)+1)
+		if c.config.Context {
+			params = append(params, "ctx")
+		}
+		for _, p := range m.Parameters {
+			params = append(params, fmt.Sprintf("params.%s", strcase.ToCamel(p.Name)))
+		}
+		if m.Bidirectional() {
+			params = append(params, "stream")
+		} else if m.Stream {
+			params = append(params, fmt.Sprintf("sendEvents[%s](stream)", c.resolveType(*m.ReturnType)))
+		}
+		return strings.Join(params, ", ")
+	}
+	funcs["toLower"] = strings.ToLower
+	funcs["hasRoute"] = model.Method.HasRoute
+	funcs["isPathVariable"] = func(m model.Method, p model.MethodParameter) bool {
+		return slices.Contains(m.Route().Variables(), p.Name)
+	}
+	funcs["hasQueryParameters"] = func(m model.Method) bool {
+		return len(m.QueryParameters()) > 0
+	}
+	funcs["isQuoted"] = isQuoted
+	funcs["isArray"] = func(p model.MethodParameter) bool {
+		return p.Type.Variant == model.TypeVariantArray
+	}
+	// the request and path variables of net/http handlers, which other
+	// routers replace
+	funcs["request"] = func() string {
+		return "r"
+	}
+	funcs["pathValue"] = func(name string) string {
+		return fmt.Sprintf("r.PathValue(%q)", name)
+	}
+	for name, f := range extra {
+		funcs[name] = f
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(go_common_template)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range sources {
+		c.template, err = tmpl.Parse(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// formats doc as a block of // comments, each line prefixed with indent
+func goDoc(doc string, indent string) string {
+	if doc == "" {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	for _, line := range strings.Split(doc, "\n") {
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%s// %s", indent, line), " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formats the default value of a field of type ty as a Go expression
+func goDefault(ty model.Type, value *model.Value) string {
+	if ty.Variant == model.TypeVariantOptional {
+		return fmt.Sprintf("ptrTo(%s)", goDefault(*ty.Inner, value))
+	}
+
+	switch ty.Name {
+	case "string":
+		return strconv.Quote(value.Text)
+	case "bool", "int", "float":
+		return value.Text
+	default:
+		return strcase.ToCamel(ty.Name) + strcase.ToCamel(value.Text)
+	}
+}
+
+// names the variable holding the compiled @pattern of a field
+func patternName(modelName string, fieldName string) string {
+	return fmt.Sprintf("%s%sPattern", strcase.ToLowerCamel(modelName), strcase.ToCamel(fieldName))
+}
+
+// returns the Go condition which is true when the field violates constraint
+func violationCondition(modelName string, f model.Field, constraint model.Annotation) string {
+	value := fmt.Sprintf("m.%s", strcase.ToCamel(f.Name))
+	guard := ""
+	if f.Type.Variant == model.TypeVariantOptional {
+		guard = fmt.Sprintf("%s != nil && ", value)
+		value = fmt.Sprintf("*%s", value)
+	}
+
+	arguments := constraint.Arguments
+	condition := ""
+	switch constraint.Name {
+	case "minLength":
+		condition = fmt.Sprintf("utf8.RuneCountInString(%s) < %s", value, arguments[0].Text)
+	case "maxLength":
+		condition = fmt.Sprintf("utf8.RuneCountInString(%s) > %s", value, arguments[0].Text)
+	case "pattern":
+		condition = fmt.Sprintf("!%s.MatchString(%s)", patternName(modelName, f.Name), value)
+	case "range":
+		condition = fmt.Sprintf("%s < %s || %s > %s", value, arguments[0].Text, value, arguments[1].Text)
+	case "minItems":
+		condition = fmt.Sprintf("len(%s) < %s", value, arguments[0].Text)
+	case "maxItems":
+		condition = fmt.Sprintf("len(%s) > %s", value, arguments[0].Text)
+	default:
+		panic("unknown constraint")
+	}
+
+	if guard != "" {
+		return fmt.Sprintf("%s(%s)", guard, condition)
+	}
+	return condition
+}
+
+// returns a quoted message describing what constraint expects
+func violationMessage(constraint model.Annotation) string {
+	arguments := constraint.Arguments
+	message := ""
+	switch constraint.Name {
+	case "minLength":
+		message = fmt.Sprintf("must be at least %s long", plural(arguments[0].Text, "character"))
+	case "maxLength":
+		message = fmt.Sprintf("must be at most %s long", plural(arguments[0].Text, "character"))
+	case "pattern":
+		message = fmt.Sprintf("must match the pattern %s", arguments[0].Text)
+	case "range":
+		message = fmt.Sprintf("must be between %s and %s", arguments[0].Text, arguments[1].Text)
+	case "minItems":
+		message = fmt.Sprintf("must have at least %s", plural(arguments[0].Text, "item"))
+	case "maxItems":
+		message = fmt.Sprintf("must have at most %s", plural(arguments[0].Text, "item"))
+	default:
+		panic("unknown constraint")
+	}
+	return strconv.Quote(message)
+}
+
+// formats a count of things, e.g. "1 item" or "2 items"
+func plural(count string, thing string) string {
+	if count == "1" {
+		return fmt.Sprintf("%s %s", count, thing)
+	}
+	return fmt.Sprintf("%s %ss", count, thing)
+}
+
+// returns the statements validating the models held by value, whose JSON path
+// is given by the Go expression path
+func (g *goGenerator) validateNested(value string, path string, ty model.Type, indent string, depth int) string {
+	if !g.validated[elementType(ty).Name] {
+		return ""
+	}
+
+	switch ty.Variant {
+	case model.TypeVariantNamed:
+		if strings.HasPrefix(value, "*") {
+			value = fmt.Sprintf("(%s)", value)
+		}
+		return fmt.Sprintf("\n%sviolations = append(violations, %s.validate(%s + \".\")...)", indent, value, path)
+	case model.TypeVariantOptional:
+		inner := g.validateNested(fmt.Sprintf("*%s", value), path, *ty.Inner, indent+"    ", depth)
+		return fmt.Sprintf("\n%sif %s != nil {%s\n%s}", indent, value, inner, indent)
+	case model.TypeVariantArray:
+		idx := fmt.Sprintf("idx%d", depth)
+		item := fmt.Sprintf("item%d", depth)
+		itemPath := fmt.Sprintf("%s + \"[\" + strconv.Itoa(%s) + \"]\"", path, idx)
+		inner := g.validateNested(item, itemPath, *ty.Inner, indent+"    ", depth+1)
+		return fmt.Sprintf("\n%sfor %s, %s := range %s {%s\n%s}", indent, idx, item, value, inner, indent)
+	default:
+		return ""
+	}
+}
+
+// returns the packages used by the Validate methods
+func validationImports(service *model.ServiceDefinition, validated map[string]bool) []string {
+	imports := make([]string, 0)
+	add := func(pkg string) {
+		if !slices.Contains(imports, pkg) {
+			imports = append(imports, pkg)
+		}
+	}
+
+	if len(validated) > 0 {
+		add("strings")
+	}
+
+	for _, m := range service.Models {
+		if !validated[m.Name] {
+			continue
+		}
+
+		for _, f := range m.Fields {
+			for _, constraint := range f.Annotations.Constraints() {
+				switch constraint.Name {
+				case "minLength", "maxLength":
+					add("unicode/utf8")
+				case "pattern":
+					add("regexp")
+				}
+			}
+
+			if validated[elementType(f.Type).Name] && strings.Contains(f.Type.String(), "[]") {
+				add("strconv")
+			}
+		}
+	}
+	return imports
+}
+
+// returns the type arguments of a bidirectional method's BidiStream: the
+// types of the messages it receives and sends
+func (g *goGenerator) streamTypes(m model.Method) string {
+	return fmt.Sprintf("%s, %s", g.resolveType(*m.StreamParameter), g.resolveType(*m.ReturnType))
+}
+
+func (g *goGenerator) resolveType(typeName model.Type) string {
+	if typeName.Variant == model.TypeVariantNamed {
+		typeConfig, found := g.config.Types[typeName.Name]
+		if !found {
+			return typeName.Name
+		}
+		return fmt.Sprintf("%s.%s", typeConfig.Namespace, typeConfig.TypeName)
+	} else if typeName.Variant == model.TypeVariantOptional {
+		inner := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("*%s", inner)
+	} else if typeName.Variant == model.TypeVariantArray {
+		inner := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("[]%s", inner)
+	} else if typeName.Variant == model.TypeVariantMap {
+		key := g.resolveType(*typeName.Key)
+		value := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("map[%s]%s", key, value)
+	} else {
+		panic("unreachable")
+	}
+}
+
+// writes the autogenerated header, the package clause and the imports needed
+// by the definition's types along with the given ones
+func (g *goGenerator) writeHeader(w io.Writer, service *model.ServiceDefinition, imports ...string) error {
+	_, err := fmt.Fprintln(w, "// This file is autogenerated. Any changes will be overwritten when regenerated.")
+	if err != nil {
+		return err
+	}
+
+	if len(service.Files) > 0 {
+		_, err = fmt.Fprintf(w, "// Source: %s\n", strings.Join(service.Files, ", "))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "package %s\n\n", g.config.Package)
+	if err != nil {
+		return err
+	}
+
+	g.validated = validatedModels(service)
+	g.hasErrors = len(service.Errors) > 0
+	g.streams = hasStreams(service)
+	hasDefaults := slices.ContainsFunc(service.Models, model.Model.HasDefaults)
+
+	if len(service.Enums) > 0 || len(service.Unions) > 0 || hasDefaults || g.hasErrors {
+		imports = append(imports, "encoding/json")
+	}
+	if len(service.Enums) > 0 || len(service.Unions) > 0 {
+		imports = append(imports, "fmt")
+	}
+	if g.config.Context {
+		imports = append(imports, "context")
+	}
+	imports = append(imports, validationImports(service, g.validated)...)
+	for _, t := range g.config.Types {
+		imports = append(imports, t.Package)
+	}
+
+	unique := make([]string, 0, len(imports))
+	for _, pkg := range imports {
+		if !slices.Contains(unique, pkg) {
+			unique = append(unique, pkg)
+		}
+	}
+
+	return g.template.ExecuteTemplate(w, "imports", unique)
+}
+
+// writes the enums, models and unions of the definition along with the
+// helpers they use
+func (g *goGenerator) writeTypes(w io.Writer, service *model.ServiceDefinition) error {
+	for _, e := range service.Enums {
+		err := g.template.ExecuteTemplate(w, "enum", e)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range service.Models {
+		err := g.template.ExecuteTemplate(w, "model", m)
+		if err != nil {
+			return err
+		}
+
+		if g.validated[m.Name] {
+			err = g.template.ExecuteTemplate(w, "validate", m)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, u := range service.Unions {
+		err := g.template.ExecuteTemplate(w, "union", u)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range service.Errors {
+		err := g.template.ExecuteTemplate(w, "model", e.Model())
+		if err != nil {
+			return err
+		}
+
+		err = g.template.ExecuteTemplate(w, "error", e)
+		if err != nil {
+			return err
+		}
+	}
+
+	if g.hasErrors {
+		err := g.template.ExecuteTemplate(w, "error_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(service.Unions) > 0 {
+		err := g.template.ExecuteTemplate(w, "union_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(g.validated) > 0 {
+		err := g.template.ExecuteTemplate(w, "validation_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if slices.ContainsFunc(service.Models, model.Model.HasDefaults) {
+		err := g.template.ExecuteTemplate(w, "default_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
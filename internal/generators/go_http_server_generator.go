@@ -0,0 +1,179 @@
+package generators
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Generates a server built on net/http handlers. The handlers are registered
+// with either a ServeMux, using Go 1.22's method patterns, or a chi router.
+type GoHttpServerGenerator struct {
+	*goGenerator
+	// "http" or "chi"
+	router string
+}
+
+//go:embed go_http_server.tmpl
+var go_http_server_template string
+
+func NewGoHttpServerGenerator(config json.RawMessage) (CodeGenerator, error) {
+	g, err := newGoGenerator(config, "go-http", nil, go_http_server_template, go_jsonrpc_server_template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GoHttpServerGenerator{g, "http"}, nil
+}
+
+func NewGoChiServerGenerator(config json.RawMessage) (CodeGenerator, error) {
+	funcs := template.FuncMap{
+		"pathValue": func(name string) string {
+			return fmt.Sprintf("chi.URLParam(r, %q)", name)
+		},
+	}
+
+	g, err := newGoGenerator(config, "go-chi", funcs, go_http_server_template, go_jsonrpc_server_template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GoHttpServerGenerator{g, "chi"}, nil
+}
+
+func (g *GoHttpServerGenerator) Generate(service *model.ServiceDefinition) error {
+	if g.config.Protocol == protocolJSONRPC {
+		err := checkJSONRPCStreams(service, "GoHttpServerGenerator")
+		if err != nil {
+			return err
+		}
+	}
+
+	err := os.MkdirAll(filepath.Dir(g.config.Output), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(g.config.Output)
+	if err != nil {
+		err = fmt.Errorf("problem opening '%s' (GoHttpServerGenerator): %w", g.config.Output, err)
+		return err
+	}
+	defer f.Close()
+
+	if g.config.Protocol == protocolJSONRPC {
+		return g.writeJSONRPCServer(f, service)
+	}
+
+	imports := []string{"encoding/json", "errors", "io", "net/http"}
+	if len(service.Errors) > 0 {
+		imports = append(imports, "slices")
+	}
+	if hasRoutes(service) {
+		imports = append(imports, "net/url")
+	}
+	if hasStreams(service) {
+		imports = append(imports, "fmt")
+	}
+	if hasBidirectionalStreams(service) {
+		imports = append(imports, "encoding/json", "errors", "io", "sync", "time", "github.com/gorilla/websocket")
+	}
+	if g.router == "chi" {
+		imports = append(imports, "github.com/go-chi/chi/v5")
+	}
+
+	err = g.writeHeader(f, service, imports...)
+	if err != nil {
+		return err
+	}
+
+	err = g.writeTypes(f, service)
+	if err != nil {
+		return err
+	}
+
+	if g.config.Context {
+		err = g.template.ExecuteTemplate(f, "context_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(service.Errors) > 0 {
+		err = g.template.ExecuteTemplate(f, "error_response_helper", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hasStreams(service) {
+		err = g.template.ExecuteTemplate(f, "event_stream_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hasBidirectionalStreams(service) {
+		err = g.template.ExecuteTemplate(f, "websocket_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = g.template.ExecuteTemplate(f, "http_helpers", nil)
+	if err != nil {
+		return err
+	}
+
+	if hasRoutes(service) {
+		err = g.template.ExecuteTemplate(f, "route_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if g.router == "http" {
+		err = g.template.ExecuteTemplate(f, "chain_helper", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, group := range groupServices(service) {
+		err = g.template.ExecuteTemplate(f, "service_interface", group)
+		if err != nil {
+			return err
+		}
+
+		err = g.template.ExecuteTemplate(f, "handler", group)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range group.Methods {
+			handler := "handler_func"
+			if m.Bidirectional() {
+				handler = "bidi_handler_func"
+			} else if m.Stream {
+				handler = "stream_handler_func"
+			}
+
+			err = g.template.ExecuteTemplate(f, handler, m)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = g.template.ExecuteTemplate(f, "register_"+g.router, group)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
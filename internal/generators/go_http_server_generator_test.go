@@ -0,0 +1,260 @@
+package generators
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const journalDefinition = `
+model Entry {
+    title string @minLength(1)
+    tags  string[]
+}
+
+@status(404)
+error NotFound {
+    id int
+}
+
+service Journal {
+    rpc GetEntry(id int) Entry throws NotFound
+    rpc SaveEntry(entry Entry)
+}
+`
+
+func TestGoHttpServerRegistersHandlers(t *testing.T) {
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "server"}, "server.go", journalDefinition)
+	expectGolden(t, "go_http_server.golden", generated)
+}
+
+func TestGoChiServerRegistersHandlers(t *testing.T) {
+	generated := generate(t, NewGoChiServerGenerator, map[string]any{"package": "server"}, "server.go", journalDefinition)
+	expectGolden(t, "go_chi_server.golden", generated)
+}
+
+func TestGoHttpServerPassesContext(t *testing.T) {
+	config := map[string]any{"package": "server", "context": true}
+	generated := generate(t, NewGoHttpServerGenerator, config, "server.go", journalDefinition)
+	expectGolden(t, "go_http_server_context.golden", generated)
+}
+
+func TestGoHttpServerStreamsEvents(t *testing.T) {
+	source := `
+model Entry {
+    title string
+}
+
+@status(403)
+error Forbidden {
+    reason string
+}
+
+rpc WatchEntries(tag string?) stream Entry throws Forbidden
+`
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "server"}, "server.go", source)
+	expectGolden(t, "go_http_server_stream.golden", generated)
+}
+
+func TestGoHttpServerUpgradesBidirectionalStreams(t *testing.T) {
+	source := `
+model Edit {
+    text string
+}
+
+rpc EditEntry(stream Edit) stream Edit
+`
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "server"}, "server.go", source)
+	expectGolden(t, "go_http_server_bidi.golden", generated)
+}
+
+func TestGoHttpServerServesJSONRPC(t *testing.T) {
+	config := map[string]any{"package": "server", "protocol": "jsonrpc"}
+	generated := generate(t, NewGoHttpServerGenerator, config, "server.go", journalDefinition)
+	expectGolden(t, "go_http_server_jsonrpc.golden", generated)
+}
+
+// A stream RPC is rejected before the output is created, so an existing file
+// is left as it was.
+func TestGoServersRejectJSONRPCStreams(t *testing.T) {
+	source := `
+model Entry {
+    title string
+}
+
+rpc WatchEntries() stream Entry
+`
+	generators := map[string]func(json.RawMessage) (CodeGenerator, error){
+		"go-echo": NewGoEchoServerGenerator,
+		"go-http": NewGoHttpServerGenerator,
+		"go-chi":  NewGoChiServerGenerator,
+	}
+	for name, newGenerator := range generators {
+		output := filepath.Join(t.TempDir(), "server.go")
+		err := os.WriteFile(output, []byte("package server\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := json.Marshal(map[string]any{"output": output, "package": "server", "protocol": "jsonrpc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := newGenerator(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = g.Generate(definition(t, source))
+		if err == nil {
+			t.Errorf("%s: expected an error for the stream RPC", name)
+		}
+		existing, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(existing) != "package server\n" {
+			t.Errorf("%s: expected the existing output to be left as it was, but got %q", name, existing)
+		}
+	}
+}
+
+func TestGoHttpServerBindsRoutes(t *testing.T) {
+	source := `
+model Entry {
+    title string
+}
+
+service Journal {
+    @http(GET, "/entries/{id}")
+    rpc GetEntry(id int, verbose bool?, tags string[]) Entry
+    @http(PUT, "/entries/{id}")
+    rpc UpdateEntry(id int, entry Entry) Entry
+}
+`
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "server"}, "server.go", source)
+	expectGolden(t, "go_http_server_routes.golden", generated)
+}
+
+const moodCountsDefinition = `
+enum Mood {
+    Happy,
+    Sad,
+}
+
+rpc CountMoods(counts map<Mood, int>, mood Mood) int
+`
+
+const moodCountsServerMain = `package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+type service struct{}
+
+func (service) CountMoods(counts map[Mood]int, mood Mood) (int, error) {
+	return counts[mood], nil
+}
+
+func main() {
+	mux := http.NewServeMux()
+	NewHandler(service{}).RegisterHandlers(mux)
+
+	bodies := []string{
+		` + "`" + `{"counts": {"Happy": 2}, "mood": "Happy"}` + "`" + `,
+		` + "`" + `{"counts": {"Angry": 2}, "mood": "Happy"}` + "`" + `,
+		` + "`" + `{"counts": {"Happy": 2}, "mood": "Angry"}` + "`" + `,
+	}
+	for _, body := range bodies {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/count_moods", strings.NewReader(body)))
+		fmt.Println(recorder.Code)
+	}
+}
+`
+
+// Unknown enum values are rejected as map keys the same way as they are
+// anywhere else.
+func TestGoHttpServerRejectsUnknownEnumKeys(t *testing.T) {
+	dir := t.TempDir()
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "main"}, "server.go", moodCountsDefinition)
+	writeFile(t, filepath.Join(dir, "server.go"), generated)
+	writeFile(t, filepath.Join(dir, "main.go"), moodCountsServerMain)
+	writeFile(t, filepath.Join(dir, "go.mod"), "module roundtrip\n\ngo 1.22\n")
+	statuses := run(t, dir, "go", "run", ".")
+
+	expected := "200\n400\n400"
+	if statuses != expected {
+		t.Errorf("expected the statuses %q, but got %q", expected, statuses)
+	}
+}
+
+const shapeDefinition = `
+model Circle {
+    radius int
+}
+
+model Square {
+    side int
+}
+
+union Shape(kind) {
+    circle Circle
+    square Square
+}
+
+rpc Area(shape Shape) int
+`
+
+const shapeMain = `package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func main() {
+	data, err := json.Marshal(Shape{Value: Square{Side: 2}})
+	fmt.Println(string(data), err)
+
+	payloads := []string{
+		` + "`" + `{"kind": "circle", "radius": 3}` + "`" + `,
+		` + "`" + `{"kind": "triangle", "side": 2}` + "`" + `,
+		` + "`" + `{"radius": 3}` + "`" + `,
+	}
+	for _, payload := range payloads {
+		var shape Shape
+		err := json.Unmarshal([]byte(payload), &shape)
+		fmt.Printf("%#v %v\n", shape.Value, err)
+	}
+}
+`
+
+func TestGoHttpServerEncodesUnions(t *testing.T) {
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "server"}, "server.go", shapeDefinition)
+	expectGolden(t, "go_http_server_union.golden", generated)
+}
+
+// A union is decoded into the variant named by its discriminator, and a
+// payload with an unknown or missing discriminator is rejected.
+func TestGoUnionsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "main"}, "server.go", shapeDefinition)
+	writeFile(t, filepath.Join(dir, "server.go"), generated)
+	writeFile(t, filepath.Join(dir, "main.go"), shapeMain)
+	writeFile(t, filepath.Join(dir, "go.mod"), "module roundtrip\n\ngo 1.22\n")
+	output := run(t, dir, "go", "run", ".")
+
+	expected := `{"kind":"square","side":2} <nil>
+main.Circle{Radius:3} <nil>
+<nil> unknown Shape kind "triangle"
+<nil> unknown Shape kind ""`
+	if output != expected {
+		t.Errorf("expected the output\n%s\nbut got\n%s", expected, output)
+	}
+}
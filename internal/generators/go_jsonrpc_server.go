@@ -0,0 +1,76 @@
+package generators
+
+import (
+	_ "embed"
+	"io"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+//go:embed go_jsonrpc_server.tmpl
+var go_jsonrpc_server_template string
+
+// writes the types and a JSON-RPC 2.0 server calling the services, which is
+// the same for every Go server since it only needs net/http. The generators
+// check for stream RPCs with checkJSONRPCStreams before creating the output.
+func (g *goGenerator) writeJSONRPCServer(w io.Writer, service *model.ServiceDefinition) error {
+	imports := []string{"bytes", "encoding/json", "errors", "fmt", "io", "net/http"}
+	if len(service.Errors) > 0 {
+		imports = append(imports, "slices")
+	}
+
+	err := g.writeHeader(w, service, imports...)
+	if err != nil {
+		return err
+	}
+
+	err = g.writeTypes(w, service)
+	if err != nil {
+		return err
+	}
+
+	if g.config.Context {
+		err = g.template.ExecuteTemplate(w, "context_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(service.Errors) > 0 {
+		err = g.template.ExecuteTemplate(w, "error_response_helper", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = g.template.ExecuteTemplate(w, "jsonrpc_helpers", nil)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groupServices(service) {
+		err = g.template.ExecuteTemplate(w, "service_interface", group)
+		if err != nil {
+			return err
+		}
+
+		err = g.template.ExecuteTemplate(w, "handler", group)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range group.Methods {
+			err = g.template.ExecuteTemplate(w, "jsonrpc_method", m)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = g.template.ExecuteTemplate(w, "register_methods", group)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+// Builds the JSON Schemas (draft 2020-12) of the definition's types. Each
+// declared type is referred to with a $ref to refPrefix followed by its name.
+type schemaBuilder struct {
+	refPrefix string
+	// schemas used in place of those of the named types, from the config
+	types map[string]json.RawMessage
+}
+
+// returns the schema of a value of type ty
+func (b schemaBuilder) typeSchema(ty model.Type) (*object, error) {
+	switch ty.Variant {
+	case model.TypeVariantNamed:
+		if raw, found := b.types[ty.Name]; found {
+			var schema map[string]any
+			err := json.Unmarshal(raw, &schema)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema for type '%s': %w", ty.Name, err)
+			}
+			return sortedObject(schema), nil
+		}
+
+		switch ty.Name {
+		case "bool":
+			return newObject().set("type", "boolean"), nil
+		case "int":
+			return newObject().set("type", "integer"), nil
+		case "float":
+			return newObject().set("type", "number"), nil
+		case "string":
+			return newObject().set("type", "string"), nil
+		case "uuid":
+			return newObject().set("type", "string").set("format", "uuid"), nil
+		case "date":
+			return newObject().set("type", "string").set("format", "date-time"), nil
+		default:
+			return newObject().set("$ref", b.refPrefix+ty.Name), nil
+		}
+	case model.TypeVariantOptional:
+		inner, err := b.typeSchema(*ty.Inner)
+		if err != nil {
+			return nil, err
+		}
+
+		// a schema with a single type can take null as another, anything
+		// else needs wrapping unless it already takes null
+		if name, ok := inner.values["type"].(string); ok {
+			return inner.set("type", []any{name, "null"}), nil
+		} else if types, ok := inner.values["type"].([]any); ok && slices.Contains(types, "null") {
+			return inner, nil
+		}
+		return newObject().set("oneOf", []any{inner, newObject().set("type", "null")}), nil
+	case model.TypeVariantArray:
+		items, err := b.typeSchema(*ty.Inner)
+		if err != nil {
+			return nil, err
+		}
+		// the Go servers send empty slices and maps which were never made as
+		// null
+		return newObject().set("type", []any{"array", "null"}).set("items", items), nil
+	case model.TypeVariantMap:
+		values, err := b.typeSchema(*ty.Inner)
+		if err != nil {
+			return nil, err
+		}
+
+		schema := newObject().set("type", []any{"object", "null"})
+		switch ty.Key.Name {
+		case "string":
+		case "int":
+			schema.set("propertyNames", newObject().set("pattern", "^-?[0-9]+$"))
+		default:
+			keys, err := b.typeSchema(*ty.Key)
+			if err != nil {
+				return nil, err
+			}
+			schema.set("propertyNames", keys)
+		}
+		return schema.set("additionalProperties", values), nil
+	default:
+		panic("unreachable")
+	}
+}
+
+// returns the schema of a field or parameter, including its doc, default and
+// constraints
+func (b schemaBuilder) fieldSchema(f model.Field) (*object, error) {
+	schema, err := b.typeSchema(f.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Doc != "" {
+		schema.set("description", f.Doc)
+	}
+	if f.Default != nil {
+		schema.set("default", jsonDefault(f.Type, *f.Default))
+	}
+	if f.Annotations.Has("deprecated") {
+		schema.set("deprecated", true)
+	}
+
+	for _, constraint := range f.Annotations.Constraints() {
+		arguments := constraint.Arguments
+		switch constraint.Name {
+		case "minLength", "maxLength", "minItems", "maxItems":
+			schema.set(constraint.Name, json.Number(arguments[0].Text))
+		case "pattern":
+			schema.set("pattern", arguments[0].Text)
+		case "range":
+			schema.set("minimum", json.Number(arguments[0].Text))
+			schema.set("maximum", json.Number(arguments[1].Text))
+		}
+	}
+	return schema, nil
+}
+
+// returns the schema of a model's JSON object. Fields which are neither
+// optional nor defaulted are required.
+func (b schemaBuilder) modelSchema(m model.Model) (*object, error) {
+	schema := newObject().set("type", "object")
+	if m.Doc != "" {
+		schema.set("description", m.Doc)
+	}
+	if m.Annotations.Has("deprecated") {
+		schema.set("deprecated", true)
+	}
+
+	properties := newObject()
+	required := make([]any, 0)
+	for _, f := range m.Fields {
+		property, err := b.fieldSchema(f)
+		if err != nil {
+			return nil, err
+		}
+		properties.set(f.JSONName(), property)
+
+		if f.Type.Variant != model.TypeVariantOptional && f.Default == nil {
+			required = append(required, f.JSONName())
+		}
+	}
+
+	schema.set("properties", properties)
+	if len(required) > 0 {
+		schema.set("required", required)
+	}
+	return schema, nil
+}
+
+func (b schemaBuilder) enumSchema(e model.Enum) *object {
+	values := make([]any, len(e.Values))
+	for idx, value := range e.Values {
+		values[idx] = value.Name
+	}
+
+	schema := newObject().set("type", "string")
+	if e.Doc != "" {
+		schema.set("description", e.Doc)
+	}
+	if e.Annotations.Has("deprecated") {
+		schema.set("deprecated", true)
+	}
+	return schema.set("enum", values)
+}
+
+// returns the schema of a union, which is one of its variants' models along
+// with the discriminator naming the variant
+func (b schemaBuilder) unionSchema(u model.Union) *object {
+	variants := make([]any, len(u.Variants))
+	name := u.DiscriminatorJSONName()
+	for idx, variant := range u.Variants {
+		discriminator := newObject().
+			set("type", "object").
+			set("properties", newObject().set(name, newObject().set("const", variant.Name))).
+			set("required", []any{name})
+
+		schema := newObject()
+		if variant.Doc != "" {
+			schema.set("description", variant.Doc)
+		}
+		variants[idx] = schema.set("allOf", []any{newObject().set("$ref", b.refPrefix+variant.Type.Name), discriminator})
+	}
+
+	schema := newObject()
+	if u.Doc != "" {
+		schema.set("description", u.Doc)
+	}
+	if u.Annotations.Has("deprecated") {
+		schema.set("deprecated", true)
+	}
+	return schema.set("oneOf", variants)
+}
+
+// returns the schema of the envelope an error is sent in
+func (b schemaBuilder) envelopeSchema(e model.Error) *object {
+	properties := newObject().
+		set("error", newObject().set("const", e.Name)).
+		set("details", newObject().set("$ref", b.refPrefix+e.Name))
+
+	return newObject().
+		set("type", "object").
+		set("properties", properties).
+		set("required", []any{"error", "details"})
+}
+
+// returns a default value as the JSON value it stands for
+func jsonDefault(ty model.Type, value model.Value) any {
+	if ty.Variant == model.TypeVariantOptional {
+		return jsonDefault(*ty.Inner, value)
+	}
+
+	switch {
+	case ty.Name == "bool":
+		return value.Text == "true"
+	case value.Kind == model.ValueKindNumber:
+		return json.Number(value.Text)
+	default:
+		return value.Text
+	}
+}
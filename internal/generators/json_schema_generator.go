@@ -0,0 +1,88 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+)
+
+type JSONSchemaConfig struct {
+	// Written as YAML when it ends in .yaml or .yml, and as JSON otherwise.
+	Output string `json:"output"`
+	// The $id of the bundle, which the schemas can be referred to by, e.g.
+	// "https://example.com/journal.schema.json#/$defs/Entry".
+	ID string `json:"id"`
+	// Maps definition types to the JSON Schemas used for them, e.g.
+	// "money": {"type": "string"}.
+	Types map[string]json.RawMessage `json:"types"`
+}
+
+// Generates a JSON Schema (draft 2020-12) bundle holding the schema of each of
+// the definition's models, enums, unions and errors under $defs.
+type JSONSchemaGenerator struct {
+	config  JSONSchemaConfig
+	schemas schemaBuilder
+}
+
+func NewJSONSchemaGenerator(config json.RawMessage) (CodeGenerator, error) {
+	if config == nil {
+		panic("config is nil")
+	}
+
+	g := new(JSONSchemaGenerator)
+	err := json.Unmarshal(config, &g.config)
+	if err != nil {
+		return nil, err
+	}
+
+	g.schemas = schemaBuilder{refPrefix: "#/$defs/", types: g.config.Types}
+	return g, nil
+}
+
+func (g *JSONSchemaGenerator) Generate(service *model.ServiceDefinition) error {
+	document, err := g.document(service)
+	if err != nil {
+		return fmt.Errorf("problem building the document (JSONSchemaGenerator): %w", err)
+	}
+
+	err = writeDocument(g.config.Output, document)
+	if err != nil {
+		return fmt.Errorf("problem writing '%s' (JSONSchemaGenerator): %w", g.config.Output, err)
+	}
+	return nil
+}
+
+func (g *JSONSchemaGenerator) document(service *model.ServiceDefinition) (*object, error) {
+	document := newObject().set("$schema", "https://json-schema.org/draft/2020-12/schema")
+	if g.config.ID != "" {
+		document.set("$id", g.config.ID)
+	}
+
+	defs := newObject()
+	for _, e := range service.Enums {
+		defs.set(e.Name, g.schemas.enumSchema(e))
+	}
+
+	for _, m := range service.Models {
+		schema, err := g.schemas.modelSchema(m)
+		if err != nil {
+			return nil, err
+		}
+		defs.set(m.Name, schema)
+	}
+
+	for _, u := range service.Unions {
+		defs.set(u.Name, g.schemas.unionSchema(u))
+	}
+
+	for _, e := range service.Errors {
+		schema, err := g.schemas.modelSchema(e.Model())
+		if err != nil {
+			return nil, err
+		}
+		defs.set(e.Name, schema)
+	}
+
+	return document.set("$defs", defs), nil
+}
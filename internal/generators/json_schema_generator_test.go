@@ -0,0 +1,38 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestJSONSchemaBundlesDefinitions(t *testing.T) {
+	config := map[string]any{"id": "https://example.com/journal.schema.json"}
+	generated := generate(t, NewJSONSchemaGenerator, config, "journal.schema.json", openAPIDefinition)
+	expectGolden(t, "json_schema.golden", generated)
+}
+
+// The Go servers send nil slices and maps as null, which the schemas accept.
+func TestJSONSchemaAcceptsNullArraysAndMaps(t *testing.T) {
+	generated := generate(t, NewJSONSchemaGenerator, map[string]any{}, "journal.schema.json", summaryDefinition)
+
+	var bundle struct {
+		Defs map[string]struct {
+			Properties map[string]struct {
+				Type any `json:"type"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	err := json.Unmarshal([]byte(generated), &bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"tags": "[array null]", "counts": "[object null]", "notes": "[array null]"}
+	for name, types := range expected {
+		property := bundle.Defs["Summary"].Properties[name]
+		if fmt.Sprint(property.Type) != types {
+			t.Errorf("expected %s to have the types %s, but got %v", name, types, property.Type)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/iancoleman/strcase"
+)
+
+type OpenAPIConfig struct {
+	// Written as YAML when it ends in .yaml or .yml, and as JSON otherwise.
+	Output      string `json:"output"`
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	// The URLs the API is served at.
+	Servers []string `json:"servers"`
+	// Maps definition types to the JSON Schemas used for them, e.g.
+	// "money": {"type": "string"}.
+	Types map[string]json.RawMessage `json:"types"`
+}
+
+// Generates an OpenAPI 3.1 document describing the RPCs as they're served
+// over HTTP, with a component schema for each of the definition's types.
+type OpenAPIGenerator struct {
+	config  OpenAPIConfig
+	schemas schemaBuilder
+}
+
+func NewOpenAPIGenerator(config json.RawMessage) (CodeGenerator, error) {
+	if config == nil {
+		panic("config is nil")
+	}
+
+	g := new(OpenAPIGenerator)
+	err := json.Unmarshal(config, &g.config)
+	if err != nil {
+		return nil, err
+	}
+
+	g.schemas = schemaBuilder{refPrefix: "#/components/schemas/", types: g.config.Types}
+	return g, nil
+}
+
+func (g *OpenAPIGenerator) Generate(service *model.ServiceDefinition) error {
+	document, err := g.document(service)
+	if err != nil {
+		return fmt.Errorf("problem building the document (OpenAPIGenerator): %w", err)
+	}
+
+	err = writeDocument(g.config.Output, document)
+	if err != nil {
+		return fmt.Errorf("problem writing '%s' (OpenAPIGenerator): %w", g.config.Output, err)
+	}
+	return nil
+}
+
+func (g *OpenAPIGenerator) document(service *model.ServiceDefinition) (*object, error) {
+	// the title defaults to the name of the definition file
+	title := g.config.Title
+	if title == "" && len(service.Files) > 0 {
+		file := filepath.Base(service.Files[len(service.Files)-1])
+		title = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	version := g.config.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	info := newObject().set("title", title).set("version", version)
+	if g.config.Description != "" {
+		info.set("description", g.config.Description)
+	}
+	document := newObject().set("openapi", "3.1.0").set("info", info)
+
+	if len(g.config.Servers) > 0 {
+		servers := make([]any, len(g.config.Servers))
+		for idx, url := range g.config.Servers {
+			servers[idx] = newObject().set("url", url)
+		}
+		document.set("servers", servers)
+	}
+
+	if len(service.Services) > 0 {
+		tags := make([]any, len(service.Services))
+		for idx, s := range service.Services {
+			tag := newObject().set("name", s.Name)
+			if s.Doc != "" {
+				tag.set("description", s.Doc)
+			}
+			tags[idx] = tag
+		}
+		document.set("tags", tags)
+	}
+
+	paths := newObject()
+	for _, m := range service.Methods {
+		operation, err := g.operation(service, m)
+		if err != nil {
+			return nil, err
+		}
+
+		route := m.Route()
+		item, found := paths.get(route.Path)
+		if !found {
+			item = newObject()
+			paths.set(route.Path, item)
+		}
+		item.(*object).set(strings.ToLower(route.Method), operation)
+	}
+	document.set("paths", paths)
+
+	schemas, err := g.componentSchemas(service)
+	if err != nil {
+		return nil, err
+	}
+	document.set("components", newObject().set("schemas", schemas))
+
+	return document, nil
+}
+
+// returns the schemas of the enums, models, unions and errors. Parameter
+// models are only included for the RPCs which send them as a body.
+func (g *OpenAPIGenerator) componentSchemas(service *model.ServiceDefinition) (*object, error) {
+	schemas := newObject()
+	for _, e := range service.Enums {
+		schemas.set(e.Name, g.schemas.enumSchema(e))
+	}
+
+	parameterModels := make(map[string]bool)
+	for _, m := range service.Methods {
+		if len(m.Parameters) > 0 {
+			parameterModels[m.ParameterType.Name] = hasBody(m)
+		}
+	}
+
+	for _, m := range service.Models {
+		if sent, found := parameterModels[m.Name]; found && !sent {
+			continue
+		}
+
+		schema, err := g.schemas.modelSchema(m)
+		if err != nil {
+			return nil, err
+		}
+		schemas.set(m.Name, schema)
+	}
+
+	for _, u := range service.Unions {
+		schemas.set(u.Name, g.schemas.unionSchema(u))
+	}
+
+	for _, e := range service.Errors {
+		schema, err := g.schemas.modelSchema(e.Model())
+		if err != nil {
+			return nil, err
+		}
+		schemas.set(e.Name, schema)
+	}
+
+	return schemas, nil
+}
+
+// returns whether the parameters of m are sent as a JSON body. Bidirectional
+// RPCs take theirs over the WebSocket instead.
+func hasBody(m model.Method) bool {
+	return len(m.Parameters) > 0 && m.Route().HasBody() && !m.Bidirectional()
+}
+
+func (g *OpenAPIGenerator) operation(service *model.ServiceDefinition, m model.Method) (*object, error) {
+	operation := newObject().set("operationId", strcase.ToLowerCamel(m.Service+m.Name))
+	if m.Service != "" {
+		operation.set("tags", []any{m.Service})
+	}
+	if m.Doc != "" {
+		operation.set("description", m.Doc)
+	}
+	if m.Annotations.Has("deprecated") {
+		operation.set("deprecated", true)
+	}
+
+	parameters := make([]any, 0)
+	route := m.Route()
+	for _, name := range route.Variables() {
+		idx := slices.IndexFunc(m.Parameters, func(p model.MethodParameter) bool { return p.Name == name })
+		parameter, err := g.parameter(m.Parameters[idx], "path")
+		if err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, parameter)
+	}
+	for _, p := range m.QueryParameters() {
+		parameter, err := g.parameter(p, "query")
+		if err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, parameter)
+	}
+	if len(parameters) > 0 {
+		operation.set("parameters", parameters)
+	}
+
+	if hasBody(m) {
+		required := slices.ContainsFunc(m.Parameters, func(p model.MethodParameter) bool {
+			return p.Type.Variant != model.TypeVariantOptional && p.Default == nil
+		})
+		schema := newObject().set("$ref", g.schemas.refPrefix+m.ParameterType.Name)
+		content := newObject().set("application/json", newObject().set("schema", schema))
+		operation.set("requestBody", newObject().set("required", required).set("content", content))
+	}
+
+	responses, err := g.responses(service, m)
+	if err != nil {
+		return nil, err
+	}
+	return operation.set("responses", responses), nil
+}
+
+func (g *OpenAPIGenerator) parameter(p model.MethodParameter, in string) (*object, error) {
+	// the parameter's doc describes the parameter rather than its schema
+	field := model.Field(p)
+	field.Doc = ""
+	schema, err := g.schemas.fieldSchema(field)
+	if err != nil {
+		return nil, err
+	}
+
+	required := in == "path" || (p.Type.Variant == model.TypeVariantNamed && p.Default == nil)
+	parameter := newObject().set("name", p.JSONName()).set("in", in)
+	if p.Doc != "" {
+		parameter.set("description", p.Doc)
+	}
+	if p.Annotations.Has("deprecated") {
+		parameter.set("deprecated", true)
+	}
+	return parameter.set("required", required).set("schema", schema), nil
+}
+
+// returns the responses of m: its result, and the envelopes of the errors it
+// throws grouped by their status codes
+func (g *OpenAPIGenerator) responses(service *model.ServiceDefinition, m model.Method) (*object, error) {
+	responses := newObject()
+
+	switch {
+	case m.Bidirectional():
+		description := fmt.Sprintf("Switches to a WebSocket carrying %s messages from the client, and {\"value\": %s} messages from the server until one holding an \"error\" ends the stream.", m.StreamParameter, m.ReturnType)
+		responses.set("101", newObject().set("description", description))
+	case m.Stream:
+		schema, err := g.schemas.typeSchema(*m.ReturnType)
+		if err != nil {
+			return nil, err
+		}
+		description := fmt.Sprintf("A stream of Server-Sent Events, each holding the JSON of a %s. An \"error\" event ends the stream with an error.", m.ReturnType)
+		content := newObject().set("text/event-stream", newObject().set("schema", schema))
+		responses.set("200", newObject().set("description", description).set("content", content))
+	case m.ReturnType != nil:
+		schema, err := g.schemas.typeSchema(*m.ReturnType)
+		if err != nil {
+			return nil, err
+		}
+		content := newObject().set("application/json", newObject().set("schema", schema))
+		responses.set("200", newObject().set("description", "OK").set("content", content))
+	default:
+		responses.set("200", newObject().set("description", "OK"))
+	}
+
+	// a bidirectional RPC's errors are sent over its WebSocket
+	if m.Bidirectional() {
+		return responses, nil
+	}
+
+	thrown := make(map[int][]model.Error)
+	statuses := make([]int, 0)
+	for _, e := range service.Errors {
+		if !slices.Contains(m.Throws, e.Name) {
+			continue
+		}
+		if _, found := thrown[e.Status()]; !found {
+			statuses = append(statuses, e.Status())
+		}
+		thrown[e.Status()] = append(thrown[e.Status()], e)
+	}
+	slices.Sort(statuses)
+
+	for _, status := range statuses {
+		names := make([]string, 0, len(thrown[status]))
+		envelopes := make([]any, 0, len(thrown[status]))
+		for _, e := range thrown[status] {
+			names = append(names, e.Name)
+			envelopes = append(envelopes, g.schemas.envelopeSchema(e))
+		}
+
+		schema := envelopes[0].(*object)
+		if len(envelopes) > 1 {
+			schema = newObject().set("oneOf", envelopes)
+		}
+		content := newObject().set("application/json", newObject().set("schema", schema))
+		response := newObject().set("description", strings.Join(names, " or ")).set("content", content)
+		responses.set(strconv.Itoa(status), response)
+	}
+
+	return responses, nil
+}
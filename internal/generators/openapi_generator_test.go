@@ -0,0 +1,118 @@
+package generators
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+const openAPIDefinition = `
+/// How an entry was written.
+enum Mood {
+    Happy,
+    Sad,
+}
+
+/// A journal entry.
+/// Entries are written once a day.
+model Entry {
+    title string @minLength(1)
+    mood  Mood
+    tags  string[] @maxItems(10)
+    note  string?
+}
+
+model Photo {
+    url string
+}
+
+union Attachment(attachment_kind) {
+    entry Entry
+    photo Photo
+}
+
+@status(404)
+error NotFound {
+    id int
+}
+
+service Journal {
+    @http(GET, "/entries/{id}")
+    rpc GetEntry(id int, verbose bool?) Entry throws NotFound
+    rpc Attach(id int, attachment Attachment)
+}
+`
+
+func TestOpenAPIDescribesServices(t *testing.T) {
+	config := map[string]any{"title": "Journal", "servers": []string{"https://example.com/api"}}
+	generated := generate(t, NewOpenAPIGenerator, config, "openapi.yaml", openAPIDefinition)
+	expectGolden(t, "openapi.yaml.golden", generated)
+}
+
+// The discriminator is named the way it's sent, which is in lower camel case.
+func TestOpenAPINamesDiscriminatorsLikeJSON(t *testing.T) {
+	generated := generate(t, NewOpenAPIGenerator, map[string]any{}, "openapi.json", openAPIDefinition)
+
+	var document struct {
+		Components struct {
+			Schemas map[string]struct {
+				OneOf []struct {
+					AllOf []struct {
+						Properties map[string]any `json:"properties"`
+						Required   []string       `json:"required"`
+					} `json:"allOf"`
+				} `json:"oneOf"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	err := json.Unmarshal([]byte(generated), &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, variant := range document.Components.Schemas["Attachment"].OneOf {
+		discriminator := variant.AllOf[1]
+		if _, found := discriminator.Properties["attachmentKind"]; !found {
+			t.Errorf("expected the discriminator property attachmentKind, but got %v", discriminator.Properties)
+		}
+		if len(discriminator.Required) != 1 || discriminator.Required[0] != "attachmentKind" {
+			t.Errorf("expected attachmentKind to be required, but got %v", discriminator.Required)
+		}
+	}
+}
+
+// The Go servers send nil slices and maps as null, which the schemas accept.
+func TestOpenAPIAcceptsNullArraysAndMaps(t *testing.T) {
+	source := `
+model Entry {
+    tags     string[]
+    counts   map<string, int>
+    comments string[]?
+}
+
+rpc GetEntry() Entry
+`
+	generated := generate(t, NewOpenAPIGenerator, map[string]any{}, "openapi.json", source)
+
+	var document struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]struct {
+					Type any `json:"type"`
+				} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	err := json.Unmarshal([]byte(generated), &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"tags": "[array null]", "counts": "[object null]", "comments": "[array null]"}
+	for name, types := range expected {
+		property := document.Components.Schemas["Entry"].Properties[name]
+		if fmt.Sprint(property.Type) != types {
+			t.Errorf("expected %s to have the types %s, but got %v", name, types, property.Type)
+		}
+	}
+}
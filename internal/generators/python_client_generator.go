@@ -0,0 +1,419 @@
+package generators
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/iancoleman/strcase"
+)
+
+type PythonClientConfig struct {
+	Output string `json:"output"`
+	// Maps definition types to Python types. Values of mapped types are passed
+	// through as they are in JSON.
+	Types map[string]string `json:"types"`
+}
+
+// Generates a Python module with a dataclass for each model and a client
+// class for each service, using only the standard library.
+type PythonClientGenerator struct {
+	config   PythonClientConfig
+	template *template.Template
+	enums    []string
+	models   []string
+	unions   []string
+}
+
+//go:embed python_client.tmpl
+var python_client_template string
+
+var pythonKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break",
+	"class", "continue", "def", "del", "elif", "else", "except", "finally",
+	"for", "from", "global", "if", "import", "in", "is", "lambda", "nonlocal",
+	"not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+}
+
+func NewPythonClientGenerator(config json.RawMessage) (CodeGenerator, error) {
+	if config == nil {
+		panic("config is nil")
+	}
+
+	c := new(PythonClientGenerator)
+
+	err := json.Unmarshal(config, &c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := make(template.FuncMap, 0)
+	funcs["toCamel"] = strcase.ToCamel
+	funcs["toLowerCamel"] = strcase.ToLowerCamel
+	funcs["toSnake"] = strcase.ToSnake
+	funcs["toScreamingSnake"] = strcase.ToScreamingSnake
+	funcs["pyName"] = pyName
+	funcs["pyType"] = c.resolveType
+	funcs["pyDefault"] = c.pyDefault
+	funcs["pyDoc"] = pyDoc
+	funcs["pyComment"] = pyComment
+	funcs["deprecated"] = func(doc string, annotations model.Annotations) string {
+		annotation, found := annotations.Find("deprecated")
+		if !found {
+			return doc
+		}
+
+		line := "Deprecated."
+		if len(annotation.Arguments) > 0 {
+			line = fmt.Sprintf("Deprecated: %s", annotation.Arguments[0].Text)
+		}
+
+		if doc == "" {
+			return line
+		}
+		return fmt.Sprintf("%s\n\n%s", doc, line)
+	}
+	funcs["encoder"] = c.encoder
+	funcs["decoder"] = c.decoder
+	funcs["convert"] = func(converter string, expr string) string {
+		if converter == "_identity" {
+			return expr
+		}
+		return fmt.Sprintf("%s(%s)", converter, expr)
+	}
+	funcs["isOptional"] = func(ty model.Type) bool {
+		return ty.Variant == model.TypeVariantOptional
+	}
+	funcs["deref"] = func(ty *model.Type) model.Type {
+		return *ty
+	}
+	funcs["hasParameters"] = func(m model.Method) bool {
+		return len(m.Parameters) > 0
+	}
+	funcs["clientName"] = func(service string) string {
+		return fmt.Sprintf("%sClient", strcase.ToCamel(service))
+	}
+	funcs["clientDoc"] = func(group serviceGroup) string {
+		line := "Calls the RPCs over HTTP."
+		if group.Name != "" {
+			line = fmt.Sprintf("Calls the %s RPCs over HTTP.", strcase.ToCamel(group.Name))
+		}
+		if group.Doc == "" {
+			return line
+		}
+		return fmt.Sprintf("%s\n\n%s", group.Doc, line)
+	}
+	funcs["pyParameters"] = func(m model.Method) string {
+		params := []string{"self"}
+		for idx, p := range m.Parameters {
+			// parameters after one with a default must be passed by keyword
+			// unless they have defaults too
+			if p.Default != nil && !slices.Contains(params, "*") {
+				laterRequired := slices.ContainsFunc(m.Parameters[idx:], func(p model.MethodParameter) bool {
+					return p.Default == nil
+				})
+				if laterRequired {
+					params = append(params, "*")
+				}
+			}
+
+			param := fmt.Sprintf("%s: %s", pyName(p.Name), c.resolveType(p.Type))
+			if p.Default != nil {
+				param = fmt.Sprintf("%s = %s", param, c.pyDefault(p.Type, p.Default))
+			}
+			params = append(params, param)
+		}
+		return strings.Join(params, ", ")
+	}
+	// the path, the JSON body and, for @http routes, the HTTP method
+	funcs["callArguments"] = func(m model.Method) string {
+		body := "None"
+		if len(m.Parameters) > 0 && m.Route().HasBody() {
+			body = "params"
+		}
+		if !m.HasRoute() {
+			return fmt.Sprintf("%q, %s", m.Path(), body)
+		}
+
+		route := m.Route()
+		path := strconv.Quote(route.Path)
+		if isBound(m) {
+			variables := make([]string, 0)
+			for _, name := range route.Variables() {
+				idx := slices.IndexFunc(m.Parameters, func(p model.MethodParameter) bool { return p.Name == name })
+				variables = append(variables, fmt.Sprintf("%q: params[%q]", name, m.Parameters[idx].JSONName()))
+			}
+			query := make([]string, 0)
+			for _, p := range m.QueryParameters() {
+				query = append(query, fmt.Sprintf("%q: params[%q]", p.JSONName(), p.JSONName()))
+			}
+			path = fmt.Sprintf("_route(%s, {%s}, {%s})", path, strings.Join(variables, ", "), strings.Join(query, ", "))
+		}
+		return fmt.Sprintf("%s, %s, %q", path, body, route.Method)
+	}
+
+	tmpl, err := template.New("python-client").Funcs(funcs).Parse(python_client_template)
+	if err != nil {
+		return nil, err
+	}
+
+	c.template = tmpl
+	return c, nil
+}
+
+// converts name to snake case, avoiding Python's keywords
+func pyName(name string) string {
+	name = strcase.ToSnake(name)
+	if slices.Contains(pythonKeywords, name) {
+		return name + "_"
+	}
+	return name
+}
+
+// formats doc as a docstring, each line prefixed with indent
+func pyDoc(doc string, indent string) string {
+	if doc == "" {
+		return ""
+	}
+
+	doc = strings.ReplaceAll(doc, `"""`, `\"\"\"`)
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		return fmt.Sprintf(`%s"""%s"""`, indent, doc)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf(`%s"""%s`, indent, lines[0]))
+	for _, line := range lines[1:] {
+		sb.WriteString("\n")
+		if line != "" {
+			sb.WriteString(indent + line)
+		}
+	}
+	sb.WriteString("\n" + indent + `"""`)
+	return sb.String()
+}
+
+// formats doc as a block of # comments, each line prefixed with indent
+func pyComment(doc string, indent string) string {
+	if doc == "" {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	for _, line := range strings.Split(doc, "\n") {
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%s# %s", indent, line), " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// formats the default value of a field of type ty as a Python expression
+func (g *PythonClientGenerator) pyDefault(ty model.Type, value *model.Value) string {
+	if ty.Variant == model.TypeVariantOptional {
+		return g.pyDefault(*ty.Inner, value)
+	}
+
+	switch ty.Name {
+	case "bool":
+		return strcase.ToCamel(value.Text)
+	case "int", "float":
+		return value.Text
+	case "string":
+		return strconv.Quote(value.Text)
+	default:
+		return fmt.Sprintf("%s.%s", strcase.ToCamel(ty.Name), strcase.ToScreamingSnake(value.Text))
+	}
+}
+
+// returns a Python callable converting a value of type ty to JSON
+func (g *PythonClientGenerator) encoder(ty model.Type) string {
+	switch ty.Variant {
+	case model.TypeVariantOptional:
+		return g.wrap("_optional", g.encoder(*ty.Inner))
+	case model.TypeVariantArray:
+		return g.wrap("_list", g.encoder(*ty.Inner))
+	case model.TypeVariantMap:
+		key := "_identity"
+		if ty.Key.Name == "int" {
+			key = "str"
+		} else if slices.Contains(g.enums, ty.Key.Name) {
+			key = "_enum_value"
+		}
+		value := g.encoder(*ty.Inner)
+		if key == "_identity" && value == "_identity" {
+			return "_identity"
+		}
+		return fmt.Sprintf("_dict(%s, %s)", key, value)
+	}
+
+	if _, found := g.config.Types[ty.Name]; found {
+		return "_identity"
+	} else if slices.Contains(g.enums, ty.Name) {
+		return "_enum_value"
+	} else if slices.Contains(g.models, ty.Name) {
+		return "_to_json"
+	} else if slices.Contains(g.unions, ty.Name) {
+		return fmt.Sprintf("_%s_to_json", strcase.ToSnake(ty.Name))
+	}
+	return "_identity"
+}
+
+// returns a Python callable converting JSON to a value of type ty
+func (g *PythonClientGenerator) decoder(ty model.Type) string {
+	switch ty.Variant {
+	case model.TypeVariantOptional:
+		return g.wrap("_optional", g.decoder(*ty.Inner))
+	case model.TypeVariantArray:
+		return g.wrap("_list", g.decoder(*ty.Inner))
+	case model.TypeVariantMap:
+		key := "_identity"
+		if ty.Key.Name == "int" {
+			key = "int"
+		} else if slices.Contains(g.enums, ty.Key.Name) {
+			key = strcase.ToCamel(ty.Key.Name)
+		}
+		value := g.decoder(*ty.Inner)
+		if key == "_identity" && value == "_identity" {
+			return "_identity"
+		}
+		return fmt.Sprintf("_dict(%s, %s)", key, value)
+	}
+
+	if _, found := g.config.Types[ty.Name]; found {
+		return "_identity"
+	} else if slices.Contains(g.enums, ty.Name) {
+		return strcase.ToCamel(ty.Name)
+	} else if slices.Contains(g.models, ty.Name) {
+		return fmt.Sprintf("%s.from_json", strcase.ToCamel(ty.Name))
+	} else if slices.Contains(g.unions, ty.Name) {
+		return fmt.Sprintf("_%s_from_json", strcase.ToSnake(ty.Name))
+	}
+	return "_identity"
+}
+
+// wraps a converter in one for optionals or lists, which isn't needed when
+// the values aren't converted
+func (g *PythonClientGenerator) wrap(wrapper string, inner string) string {
+	if inner == "_identity" {
+		return "_identity"
+	}
+	return fmt.Sprintf("%s(%s)", wrapper, inner)
+}
+
+func (g *PythonClientGenerator) resolveType(typeName model.Type) string {
+	if typeName.Variant == model.TypeVariantNamed {
+		if alias, found := g.config.Types[typeName.Name]; found {
+			return alias
+		}
+
+		switch typeName.Name {
+		case "bool", "int", "float":
+			return typeName.Name
+		case "string", "uuid", "date":
+			return "str"
+		}
+		return strcase.ToCamel(typeName.Name)
+	} else if typeName.Variant == model.TypeVariantOptional {
+		inner := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("%s | None", inner)
+	} else if typeName.Variant == model.TypeVariantArray {
+		inner := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("list[%s]", inner)
+	} else if typeName.Variant == model.TypeVariantMap {
+		key := g.resolveType(*typeName.Key)
+		value := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("dict[%s, %s]", key, value)
+	} else {
+		panic("unreachable")
+	}
+}
+
+func (g *PythonClientGenerator) Generate(service *model.ServiceDefinition) error {
+	err := checkBidirectionalStreams(service, "PythonClientGenerator")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(g.config.Output), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(g.config.Output)
+	if err != nil {
+		err = fmt.Errorf("problem opening '%s' (PythonClientGenerator): %w", g.config.Output, err)
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, "# This file is autogenerated. Any changes will be overwritten when regenerated.")
+	if err != nil {
+		return err
+	}
+
+	if len(service.Files) > 0 {
+		_, err = fmt.Fprintf(f, "# Source: %s\n", strings.Join(service.Files, ", "))
+		if err != nil {
+			return err
+		}
+	}
+
+	g.enums, g.models, g.unions = nil, nil, nil
+	for _, e := range service.Enums {
+		g.enums = append(g.enums, e.Name)
+	}
+	for _, m := range service.Models {
+		g.models = append(g.models, m.Name)
+	}
+	for _, u := range service.Unions {
+		g.unions = append(g.unions, u.Name)
+	}
+
+	features := map[string]bool{"Streams": hasStreams(service), "Routes": hasRoutes(service)}
+	err = g.template.ExecuteTemplate(f, "prelude", features)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range service.Enums {
+		err = g.template.ExecuteTemplate(f, "enum", e)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range service.Models {
+		err = g.template.ExecuteTemplate(f, "model", m)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, u := range service.Unions {
+		err = g.template.ExecuteTemplate(f, "union", u)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = g.template.ExecuteTemplate(f, "client_helpers", features)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groupServices(service) {
+		err = g.template.ExecuteTemplate(f, "client", group)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package generators
+
+import "testing"
+
+func TestPythonClientGeneratesDataclasses(t *testing.T) {
+	source := `
+enum Mood {
+    Happy,
+    Sad,
+}
+
+model Entry {
+    title string
+    mood  Mood
+    tags  string[]
+    note  string? @json("entry_note")
+}
+
+union Item(item_kind) {
+    entry Entry
+}
+
+@status(404)
+error NotFound {
+    id int
+}
+
+service Journal {
+    rpc GetItem(id int) Item throws NotFound
+    rpc ListEntries(limit int = 20) Entry[]
+}
+`
+	generated := generate(t, NewPythonClientGenerator, map[string]any{}, "client.py", source)
+	expectGolden(t, "python_client.golden", generated)
+}
+
+func TestPythonClientCallsRoutes(t *testing.T) {
+	generated := generate(t, NewPythonClientGenerator, map[string]any{}, "client.py", routesDefinition)
+	expectGolden(t, "python_client_routes.golden", generated)
+}
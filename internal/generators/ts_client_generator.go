@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 
@@ -13,65 +14,123 @@ import (
 	"github.com/iancoleman/strcase"
 )
 
-type TypescriptClientConfig struct {
-	Output string            `json:"output"`
-	Types  map[string]string `json:"types"`
-}
-
 type TypescriptClientGenerator struct {
-	config   TypescriptClientConfig
-	template *template.Template
+	*tsGenerator
 }
 
 //go:embed ts_client.tmpl
 var ts_client_template string
 
 func NewTypescriptClientGenerator(config json.RawMessage) (CodeGenerator, error) {
-	if config == nil {
-		panic("config is nil")
-	}
-
-	c := new(TypescriptClientGenerator)
-
-	err := json.Unmarshal(config, &c.config)
-	if err != nil {
-		return nil, err
-	}
+	// the generator isn't made until the templates are parsed, but the methods
+	// func needs it to execute them
+	var c *TypescriptClientGenerator
 
 	funcs := make(template.FuncMap, 0)
-	funcs["toCamel"] = strcase.ToCamel
-	funcs["toLowerCamel"] = strcase.ToLowerCamel
-	funcs["toSnake"] = strcase.ToSnake
-	funcs["resolveType"] = c.resolveType
 	funcs["joinParameters"] = func(m model.Method) string {
 		params := make([]string, len(m.Parameters))
 		for idx, p := range m.Parameters {
 			params[idx] = fmt.Sprintf("%s: %s", strcase.ToLowerCamel(p.Name), c.resolveType(p.Type))
+			if p.Default != nil {
+				params[idx] = fmt.Sprintf("%s = %s", params[idx], tsDefault(p.Type, *p.Default))
+			}
 		}
 		return strings.Join(params, ", ")
 	}
-	funcs["returnType"] = func(m model.Method) string {
+	funcs["fetchedType"] = func(m model.Method) string {
 		if m.ReturnType == nil {
 			return "void"
-		} else {
-			return c.resolveType(*m.ReturnType)
+		} else if c.config.Validate {
+			return "unknown"
+		}
+		return c.resolveType(*m.ReturnType)
+	}
+	funcs["decodeResponse"] = func(m model.Method) string {
+		if m.ReturnType == nil || !c.config.Validate {
+			return ""
+		}
+		return fmt.Sprintf(`.then((response) => %s(response, "response"))`, c.decoder(*m.ReturnType))
+	}
+	funcs["endpoint"] = func(m model.Method) string {
+		if c.config.Protocol == protocolJSONRPC {
+			return m.QualifiedName()
+		}
+		return m.Path()
+	}
+	// routes only apply to plain HTTP, JSON-RPC calls each method by name
+	funcs["hasRoute"] = func(m model.Method) bool {
+		return c.config.Protocol != protocolJSONRPC && m.HasRoute()
+	}
+	funcs["routeURL"] = func(m model.Method) string {
+		route := m.Route()
+		segments := strings.Split(route.Path, "/")
+		for idx, segment := range segments {
+			if name, found := model.PathVariable(segment); found {
+				segments[idx] = fmt.Sprintf("${encodeURIComponent(%s)}", strcase.ToLowerCamel(name))
+			}
+		}
+		url := fmt.Sprintf("`%s`", strings.Join(segments, "/"))
+
+		query := make([]string, 0)
+		for _, p := range m.QueryParameters() {
+			name := strcase.ToLowerCamel(p.Name)
+			if p.JSONName() == name {
+				query = append(query, name)
+			} else {
+				query = append(query, fmt.Sprintf("%s: %s", propertyName(p.JSONName()), name))
+			}
+		}
+		if len(query) == 0 {
+			return url
 		}
+		return fmt.Sprintf("withQuery(%s, { %s })", url, strings.Join(query, ", "))
 	}
-	funcs["hasParameters"] = func(m model.Method) bool {
-		return len(m.Parameters) > 0
+	funcs["streamParameterType"] = func(m model.Method) string {
+		return c.resolveType(*m.StreamParameter)
+	}
+	funcs["decodeMessage"] = func(m model.Method, value string) string {
+		if c.config.Validate {
+			return fmt.Sprintf(`%s(%s, "response")`, c.decoder(*m.ReturnType), value)
+		}
+		return fmt.Sprintf("%s as %s", value, c.resolveType(*m.ReturnType))
+	}
+	funcs["indent"] = func(text string) string {
+		lines := strings.Split(text, "\n")
+		for idx, line := range lines {
+			if line != "" {
+				lines[idx] = "    " + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	funcs["methods"] = func(methods []model.Method) (string, error) {
+		sb := strings.Builder{}
+		for _, m := range methods {
+			err := c.template.ExecuteTemplate(&sb, "method", m)
+			if err != nil {
+				return "", err
+			}
+		}
+		return strings.TrimRight(sb.String(), "\n"), nil
 	}
 
-	tmpl, err := template.New("ts-client").Funcs(funcs).Parse(ts_client_template)
+	g, err := newTsGenerator(config, "ts-client", ts_client_template, funcs)
 	if err != nil {
 		return nil, err
 	}
 
-	c.template = tmpl
-
+	c = &TypescriptClientGenerator{g}
 	return c, nil
 }
 
 func (g *TypescriptClientGenerator) Generate(service *model.ServiceDefinition) error {
+	if g.config.Protocol == protocolJSONRPC {
+		err := checkJSONRPCStreams(service, "TypescriptClientGenerator")
+		if err != nil {
+			return err
+		}
+	}
+
 	err := os.MkdirAll(filepath.Dir(g.config.Output), os.ModePerm)
 	if err != nil {
 		return err
@@ -79,51 +138,74 @@ func (g *TypescriptClientGenerator) Generate(service *model.ServiceDefinition) e
 
 	f, err := os.Create(g.config.Output)
 	if err != nil {
-		err = fmt.Errorf("problem opening '%s' (GoEchoServerGenerator): %w", g.config.Output, err)
+		err = fmt.Errorf("problem opening '%s' (TypescriptClientGenerator): %w", g.config.Output, err)
 		return err
 	}
 	defer f.Close()
 
-	_, err = fmt.Fprintln(f, "// This file is autogenerated. Any changes will be overwritten when regenerated.")
+	err = g.writeHeader(f, service)
 	if err != nil {
 		return err
 	}
 
-	for _, m := range service.Models {
-		err = g.template.ExecuteTemplate(f, "model", m)
-		if err != nil {
-			return err
-		}
+	// parameter models are only ever sent, so they're never decoded
+	err = g.writeTypes(f, service, false)
+	if err != nil {
+		return err
 	}
 
-	_, err = f.WriteString("type Fetcher<P = unknown, R = unknown> = (url: string, params: P) => Promise<R>;\n")
+	if g.config.Protocol == protocolJSONRPC {
+		notifications := make([]string, 0)
+		for _, m := range service.Methods {
+			// an RPC which throws needs a response to report its errors
+			if m.ReturnType == nil && len(m.Throws) == 0 {
+				notifications = append(notifications, m.QualifiedName())
+			}
+		}
+
+		data := map[string]any{"Errors": service.Errors, "Notifications": notifications}
+		err = g.template.ExecuteTemplate(f, "jsonrpc_helpers", data)
+	} else {
+		// withQuery is only needed by routes with query parameters
+		query := slices.ContainsFunc(service.Methods, func(m model.Method) bool {
+			return m.HasRoute() && len(m.QueryParameters()) > 0
+		})
+		data := map[string]any{"Routes": hasRoutes(service), "Query": query, "Errors": service.Errors}
+		err = g.template.ExecuteTemplate(f, "http_helpers", data)
+	}
 	if err != nil {
 		return err
 	}
 
-	for _, m := range service.Methods {
-		err = g.template.ExecuteTemplate(f, "method", m)
+	if hasStreams(service) {
+		err = g.template.ExecuteTemplate(f, "stream_helpers", nil)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
-}
 
-func (g *TypescriptClientGenerator) resolveType(typeName model.Type) string {
-	if typeName.Variant == model.TypeVariantNamed {
-		alias, found := g.config.Types[typeName.Name]
-		if !found {
-			return typeName.Name
-		}
-		return alias
-	} else if typeName.Variant == model.TypeVariantOptional {
-		inner := g.resolveType(*typeName.Inner)
-		return fmt.Sprintf("%s | null", inner)
-	} else if typeName.Variant == model.TypeVariantArray {
-		inner := g.resolveType(*typeName.Inner)
-		return fmt.Sprintf("%s[]", inner)
-	} else {
-		panic("unreachable")
+	if hasBidirectionalStreams(service) {
+		err = g.template.ExecuteTemplate(f, "websocket_helpers", service.Errors)
+		if err != nil {
+			return err
+		}
 	}
+
+	for _, group := range groupServices(service) {
+		if group.Name == "" {
+			for _, m := range group.Methods {
+				err = g.template.ExecuteTemplate(f, "method", m)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		err = g.template.ExecuteTemplate(f, "namespace", group)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,177 @@
+package generators
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Returns the command running a TypeScript file, skipping the test when none
+// is installed.
+func typescriptRunner(t *testing.T) []string {
+	t.Helper()
+	runners := [][]string{{"tsx"}, {"deno", "run", "--allow-read"}, {"bun", "run"}}
+	for _, runner := range runners {
+		if _, err := exec.LookPath(runner[0]); err == nil {
+			return runner
+		}
+	}
+	t.Skip("no TypeScript runner (tsx, deno or bun) is installed")
+	return nil
+}
+
+// Runs the command in dir, failing the test when it fails, and returns what it
+// printed.
+func run(t *testing.T, dir string, command ...string) string {
+	t.Helper()
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%s: %s", strings.Join(command, " "), err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+const summaryDefinition = `
+model Summary {
+    tags   string[]
+    counts map<string, int>
+    notes  string[]?
+}
+
+rpc GetSummary() Summary
+`
+
+const summaryServerMain = `package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+)
+
+type service struct{}
+
+func (service) GetSummary() (Summary, error) {
+	return Summary{}, nil
+}
+
+func main() {
+	mux := http.NewServeMux()
+	NewHandler(service{}).RegisterHandlers(mux)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/get_summary", nil))
+	fmt.Print(recorder.Body.String())
+}
+`
+
+const summaryClientMain = `import { getSummary } from "./client.ts";
+
+const body = %s;
+getSummary(async () => body).then((summary) => console.log(JSON.stringify(summary)));
+`
+
+// The Go servers encode nil slices and maps as null, which the validating
+// client reads as empty.
+func TestTypescriptClientDecodesGoResponses(t *testing.T) {
+	runner := typescriptRunner(t)
+
+	server := t.TempDir()
+	generated := generate(t, NewGoHttpServerGenerator, map[string]any{"package": "main"}, "server.go", summaryDefinition)
+	writeFile(t, filepath.Join(server, "server.go"), generated)
+	writeFile(t, filepath.Join(server, "main.go"), summaryServerMain)
+	writeFile(t, filepath.Join(server, "go.mod"), "module roundtrip\n\ngo 1.22\n")
+	body := run(t, server, "go", "run", ".")
+
+	client := t.TempDir()
+	generated = generate(t, NewTypescriptClientGenerator, map[string]any{"validate": true, "types": map[string]string{"int": "number"}}, "client.ts", summaryDefinition)
+	writeFile(t, filepath.Join(client, "client.ts"), generated)
+	writeFile(t, filepath.Join(client, "main.ts"), fmt.Sprintf(summaryClientMain, body))
+	decoded := run(t, client, append(runner, "main.ts")...)
+
+	expected := `{"tags":[],"counts":{},"notes":null}`
+	if decoded != expected {
+		t.Errorf("expected the client to decode %s as %s, but got %s", body, expected, decoded)
+	}
+}
+
+func TestTypescriptClientValidatesResponses(t *testing.T) {
+	source := `
+enum Mood {
+    Happy,
+    Sad,
+}
+
+model Entry {
+    title string
+    mood  Mood
+    tags  string[]
+    notes map<string, string>?
+}
+
+union Item(kind) {
+    entry Entry
+}
+
+rpc ListItems(count int = 10) Item[]
+`
+	config := map[string]any{"validate": true, "types": map[string]string{"int": "number"}}
+	generated := generate(t, NewTypescriptClientGenerator, config, "client.ts", source)
+	expectGolden(t, "ts_client_validate.golden", generated)
+}
+
+func TestTypescriptClientThrowsServiceErrors(t *testing.T) {
+	source := `
+model Entry {
+    title string
+}
+
+@status(404)
+error NotFound {
+    id int
+}
+
+service Journal {
+    rpc GetEntry(id int) Entry throws NotFound
+    @http(GET, "/entries/{id}")
+    rpc FindEntry(id int, verbose bool?) Entry throws NotFound
+}
+`
+	config := map[string]any{"types": map[string]string{"int": "number", "bool": "boolean"}}
+	generated := generate(t, NewTypescriptClientGenerator, config, "client.ts", source)
+	expectGolden(t, "ts_client_errors.golden", generated)
+}
+
+// RPCs without a return value are sent as notifications, unless they throw
+// errors, which only a response can report.
+func TestTypescriptClientSendsJSONRPCNotifications(t *testing.T) {
+	source := `
+@status(404)
+error NotFound {
+    id int
+}
+
+service Journal {
+    rpc Ping()
+    rpc DeleteEntry(id int) throws NotFound
+    rpc CountEntries() int
+}
+`
+	config := map[string]any{"protocol": "jsonrpc", "types": map[string]string{"int": "number"}}
+	generated := generate(t, NewTypescriptClientGenerator, config, "client.ts", source)
+	expectGolden(t, "ts_client_jsonrpc.golden", generated)
+}
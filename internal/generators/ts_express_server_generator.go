@@ -0,0 +1,166 @@
+package generators
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/iancoleman/strcase"
+)
+
+// Generates a service interface for each service and a function registering
+// its routes with an Express router. It's configured the same way as the
+// TypeScript client, and validate decodes each request instead.
+type TypescriptExpressServerGenerator struct {
+	*tsGenerator
+}
+
+//go:embed ts_express_server.tmpl
+var ts_express_server_template string
+
+func NewTypescriptExpressServerGenerator(config json.RawMessage) (CodeGenerator, error) {
+	var c *TypescriptExpressServerGenerator
+
+	funcs := make(template.FuncMap, 0)
+	funcs["serviceInterfaceName"] = func(service string) string {
+		return fmt.Sprintf("%sService", strcase.ToCamel(service))
+	}
+	funcs["signatureParameters"] = func(m model.Method) string {
+		params := make([]string, len(m.Parameters))
+		for idx, p := range m.Parameters {
+			params[idx] = fmt.Sprintf("%s: %s", strcase.ToLowerCamel(p.Name), c.resolveType(p.Type))
+		}
+		if m.Stream {
+			params = append(params, fmt.Sprintf("send: (value: %s) => void", c.resolveType(*m.ReturnType)))
+		}
+		return strings.Join(params, ", ")
+	}
+	funcs["destructureParameters"] = func(m model.Method) string {
+		params := make([]string, len(m.Parameters))
+		for idx, p := range m.Parameters {
+			name := strcase.ToLowerCamel(p.Name)
+			params[idx] = name
+			if p.JSONName() != name {
+				property := p.JSONName()
+				if !identifierPattern.MatchString(property) {
+					property = strconv.Quote(property)
+				}
+				params[idx] = fmt.Sprintf("%s: %s", property, name)
+			}
+			if p.Default != nil {
+				params[idx] = fmt.Sprintf("%s = %s", params[idx], tsDefault(p.Type, *p.Default))
+			}
+		}
+		return strings.Join(params, ", ")
+	}
+	funcs["toLower"] = strings.ToLower
+	// Express writes path variables as :id rather than {id}
+	funcs["expressPath"] = func(m model.Method) string {
+		segments := strings.Split(m.Route().Path, "/")
+		for idx, segment := range segments {
+			if name, found := model.PathVariable(segment); found {
+				segments[idx] = ":" + name
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+	funcs["isBound"] = isBound
+	funcs["isPathVariable"] = func(m model.Method, p model.MethodParameter) bool {
+		return slices.Contains(m.Route().Variables(), p.Name)
+	}
+	funcs["isQuoted"] = isQuoted
+	funcs["isArray"] = func(p model.MethodParameter) bool {
+		return p.Type.Variant == model.TypeVariantArray
+	}
+	funcs["callArguments"] = func(m model.Method) string {
+		args := make([]string, len(m.Parameters))
+		for idx, p := range m.Parameters {
+			args[idx] = strcase.ToLowerCamel(p.Name)
+		}
+		if m.Stream {
+			args = append(args, "stream.send")
+		}
+		return strings.Join(args, ", ")
+	}
+
+	g, err := newTsGenerator(config, "ts-express", ts_express_server_template, funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &TypescriptExpressServerGenerator{g}
+	return c, nil
+}
+
+func (g *TypescriptExpressServerGenerator) Generate(service *model.ServiceDefinition) error {
+	err := checkBidirectionalStreams(service, "TypescriptExpressServerGenerator")
+	if err != nil {
+		return err
+	}
+
+	if g.config.Protocol == protocolJSONRPC {
+		return fmt.Errorf("the jsonrpc protocol isn't supported (TypescriptExpressServerGenerator)")
+	}
+
+	err = os.MkdirAll(filepath.Dir(g.config.Output), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(g.config.Output)
+	if err != nil {
+		err = fmt.Errorf("problem opening '%s' (TypescriptExpressServerGenerator): %w", g.config.Output, err)
+		return err
+	}
+	defer f.Close()
+
+	err = g.writeHeader(f, service)
+	if err != nil {
+		return err
+	}
+
+	err = g.template.ExecuteTemplate(f, "imports", nil)
+	if err != nil {
+		return err
+	}
+
+	// requests are decoded with the parameter models' decoders
+	err = g.writeTypes(f, service, true)
+	if err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(service.Methods, isBound) {
+		err = g.template.ExecuteTemplate(f, "route_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hasStreams(service) {
+		err = g.template.ExecuteTemplate(f, "stream_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, group := range groupServices(service) {
+		err = g.template.ExecuteTemplate(f, "service_interface", group)
+		if err != nil {
+			return err
+		}
+
+		err = g.template.ExecuteTemplate(f, "register", group)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
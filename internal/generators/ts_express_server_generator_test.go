@@ -0,0 +1,31 @@
+package generators
+
+import "testing"
+
+func TestTypescriptExpressServerValidatesParams(t *testing.T) {
+	source := `
+model Entry {
+    title string
+    tags  string[]
+}
+
+@status(404)
+error NotFound {
+    id int
+}
+
+service Journal {
+    rpc GetEntry(id int @json("entry_id")) Entry throws NotFound
+    rpc ListEntries(limit int = 20) Entry[]
+}
+`
+	config := map[string]any{"validate": true, "types": map[string]string{"int": "number"}}
+	generated := generate(t, NewTypescriptExpressServerGenerator, config, "server.ts", source)
+	expectGolden(t, "ts_express_server.golden", generated)
+}
+
+func TestTypescriptExpressServerBindsRoutes(t *testing.T) {
+	config := map[string]any{"validate": true, "types": map[string]string{"bool": "boolean"}}
+	generated := generate(t, NewTypescriptExpressServerGenerator, config, "server.ts", routesDefinition)
+	expectGolden(t, "ts_express_server_routes.golden", generated)
+}
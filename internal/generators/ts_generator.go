@@ -0,0 +1,339 @@
+package generators
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/iancoleman/strcase"
+)
+
+type TypescriptClientConfig struct {
+	Output string            `json:"output"`
+	Types  map[string]string `json:"types"`
+	// Generates a decoder for every type. Clients decode each response with
+	// them, and servers each request.
+	Validate bool `json:"validate"`
+	// Either "http", the default, or "jsonrpc" for a single JSON-RPC 2.0
+	// endpoint. Only the client supports JSON-RPC.
+	Protocol string `json:"protocol"`
+}
+
+// What the TypeScript generators have in common: their config, the templates
+// for the definition's types and the funcs those templates use.
+type tsGenerator struct {
+	config   TypescriptClientConfig
+	template *template.Template
+	// the names of the models, enums and unions being generated
+	declared map[string]bool
+}
+
+//go:embed ts_common.tmpl
+var ts_common_template string
+
+// parses the config and the common templates along with source, which can use
+// the extra funcs on top of the common ones
+func newTsGenerator(config json.RawMessage, name string, source string, extra template.FuncMap) (*tsGenerator, error) {
+	if config == nil {
+		panic("config is nil")
+	}
+
+	c := new(tsGenerator)
+
+	err := json.Unmarshal(config, &c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkProtocol(c.config.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := make(template.FuncMap, 0)
+	funcs["toCamel"] = strcase.ToCamel
+	funcs["toLowerCamel"] = strcase.ToLowerCamel
+	funcs["toSnake"] = strcase.ToSnake
+	funcs["resolveType"] = c.resolveType
+	funcs["returnType"] = func(m model.Method) string {
+		if m.ReturnType == nil {
+			return "void"
+		} else {
+			return c.resolveType(*m.ReturnType)
+		}
+	}
+	funcs["hasParameters"] = func(m model.Method) bool {
+		return len(m.Parameters) > 0
+	}
+	funcs["validating"] = func() bool {
+		return c.config.Validate
+	}
+	funcs["decoder"] = c.decoder
+	funcs["tsDoc"] = tsDoc
+	funcs["deprecated"] = tsDeprecated
+	funcs["propertyName"] = propertyName
+	funcs["methodDoc"] = func(m model.Method) string {
+		lines := make([]string, 0)
+		if doc := tsDeprecated(m.Doc, m.Annotations); doc != "" {
+			lines = append(lines, doc)
+		}
+		for _, p := range m.Parameters {
+			if p.Doc != "" {
+				lines = append(lines, fmt.Sprintf("@param %s %s", strcase.ToLowerCamel(p.Name), p.Doc))
+			}
+		}
+		if len(m.Throws) > 0 {
+			lines = append(lines, fmt.Sprintf("@throws {ServiceError} holding %s", strings.Join(m.Throws, " or ")))
+		}
+		return strings.Join(lines, "\n")
+	}
+	funcs["errorNames"] = func(m model.Method) string {
+		names := make([]string, len(m.Throws))
+		for idx, name := range m.Throws {
+			names[idx] = fmt.Sprintf("%q", name)
+		}
+		return strings.Join(names, ", ")
+	}
+	for name, f := range extra {
+		funcs[name] = f
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(ts_common_template)
+	if err != nil {
+		return nil, err
+	}
+
+	c.template, err = tmpl.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// writes the autogenerated header
+func (g *tsGenerator) writeHeader(w io.Writer, service *model.ServiceDefinition) error {
+	_, err := fmt.Fprintln(w, "// This file is autogenerated. Any changes will be overwritten when regenerated.")
+	if err != nil {
+		return err
+	}
+
+	if len(service.Files) > 0 {
+		_, err = fmt.Fprintf(w, "// Source: %s\n", strings.Join(service.Files, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writes the enums, models and unions of the definition, along with their
+// decoders when validating. decodeParams says whether the parameter models
+// need decoders too.
+func (g *tsGenerator) writeTypes(w io.Writer, service *model.ServiceDefinition, decodeParams bool) error {
+	g.declared = make(map[string]bool)
+	for _, e := range service.Enums {
+		g.declared[e.Name] = true
+	}
+	for _, m := range service.Models {
+		g.declared[m.Name] = true
+	}
+	for _, u := range service.Unions {
+		g.declared[u.Name] = true
+	}
+	for _, e := range service.Errors {
+		g.declared[e.Name] = true
+	}
+
+	if g.config.Validate {
+		err := g.template.ExecuteTemplate(w, "decode_helpers", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range service.Enums {
+		err := g.template.ExecuteTemplate(w, "enum", e)
+		if err != nil {
+			return err
+		}
+
+		if g.config.Validate {
+			err = g.template.ExecuteTemplate(w, "enum_decoder", e)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, m := range service.Models {
+		err := g.template.ExecuteTemplate(w, "model", m)
+		if err != nil {
+			return err
+		}
+
+		isParams := slices.ContainsFunc(service.Methods, func(method model.Method) bool {
+			return method.ParameterType.Name == m.Name
+		})
+		if g.config.Validate && (decodeParams || !isParams) {
+			err = g.template.ExecuteTemplate(w, "model_decoder", m)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, u := range service.Unions {
+		err := g.template.ExecuteTemplate(w, "union", u)
+		if err != nil {
+			return err
+		}
+
+		if g.config.Validate {
+			err = g.template.ExecuteTemplate(w, "union_decoder", u)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, e := range service.Errors {
+		err := g.template.ExecuteTemplate(w, "model", e.Model())
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(service.Errors) > 0 {
+		err := g.template.ExecuteTemplate(w, "error_helpers", service.Errors)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formats the default value of a field of type ty as a TypeScript expression
+// quotes name when it can't be written as a bare property name
+func propertyName(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func tsDefault(ty model.Type, value model.Value) string {
+	if ty.Variant == model.TypeVariantOptional {
+		return tsDefault(*ty.Inner, value)
+	}
+
+	switch ty.Name {
+	case "bool", "int", "float":
+		return value.Text
+	default:
+		// strings and enum values
+		return strconv.Quote(value.Text)
+	}
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// adds a @deprecated tag to doc when the declaration is annotated with @deprecated
+func tsDeprecated(doc string, annotations model.Annotations) string {
+	annotation, found := annotations.Find("deprecated")
+	if !found {
+		return doc
+	}
+
+	tag := "@deprecated"
+	if len(annotation.Arguments) > 0 {
+		tag = fmt.Sprintf("@deprecated %s", annotation.Arguments[0].Text)
+	}
+
+	if doc == "" {
+		return tag
+	}
+	return fmt.Sprintf("%s\n%s", doc, tag)
+}
+
+// formats doc as a TSDoc block, each line prefixed with indent
+func tsDoc(doc string, indent string) string {
+	if doc == "" {
+		return ""
+	}
+
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		return fmt.Sprintf("%s/** %s */\n", indent, doc)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%s * %s", indent, line), " "))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent + " */\n")
+	return sb.String()
+}
+
+// returns an expression for the Decoder of ty
+func (g *tsGenerator) decoder(ty model.Type) string {
+	switch ty.Variant {
+	case model.TypeVariantOptional:
+		return fmt.Sprintf("decodeOptional(%s)", g.decoder(*ty.Inner))
+	case model.TypeVariantArray:
+		return fmt.Sprintf("decodeArray(%s)", g.decoder(*ty.Inner))
+	case model.TypeVariantMap:
+		return fmt.Sprintf("decodeRecord<%s, %s>(%s)", g.resolveType(*ty.Key), g.resolveType(*ty.Inner), g.decoder(*ty.Inner))
+	}
+
+	switch ty.Name {
+	case "bool":
+		return "decodeBoolean"
+	case "int":
+		return "decodeInteger"
+	case "float":
+		return "decodeNumber"
+	case "string", "uuid", "date":
+		return "decodeString"
+	}
+
+	if g.declared[ty.Name] {
+		return fmt.Sprintf("decode%s", strcase.ToCamel(ty.Name))
+	}
+
+	// custom types can't be checked
+	return fmt.Sprintf("(value: unknown) => value as %s", g.resolveType(ty))
+}
+
+func (g *tsGenerator) resolveType(typeName model.Type) string {
+	if typeName.Variant == model.TypeVariantNamed {
+		alias, found := g.config.Types[typeName.Name]
+		if !found {
+			return typeName.Name
+		}
+		return alias
+	} else if typeName.Variant == model.TypeVariantOptional {
+		inner := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("%s | null", inner)
+	} else if typeName.Variant == model.TypeVariantArray {
+		inner := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("%s[]", inner)
+	} else if typeName.Variant == model.TypeVariantMap {
+		key := g.resolveType(*typeName.Key)
+		value := g.resolveType(*typeName.Inner)
+		return fmt.Sprintf("Record<%s, %s>", key, value)
+	} else {
+		panic("unreachable")
+	}
+}
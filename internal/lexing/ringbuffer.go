@@ -65,13 +65,13 @@ func (q *RingBuffer[T]) At(n int) (T, bool) {
 
 func (q *RingBuffer[T]) resize() {
 	cap := len(q.data) * 2
-	back := len(q.data) - 1
+	back := q.Size()
 	newData := make([]T, cap)
 	if q.front > q.back {
-		copy(newData, q.data[q.front:q.back-1])
+		n := copy(newData, q.data[q.front:])
+		copy(newData[n:], q.data[:q.back])
 	} else if q.front <= q.back {
-		copy(newData, q.data[q.front:])
-		copy(newData, q.data[:q.back-1])
+		copy(newData, q.data[q.front:q.back])
 	}
 
 	q.data = newData
@@ -112,6 +112,32 @@ func TestRingBufferRandomAccess(t *testing.T) {
 	ExpectEqual(t, "found", false, ok)
 }
 
+func TestRingBufferPushOverCapacityAfterWrapping(t *testing.T) {
+	buf := NewRingBuffer[int](4)
+
+	buf.Push(1)
+	buf.Push(2)
+	buf.Push(3)
+	buf.Pop()
+	buf.Pop()
+	buf.Push(4)
+	buf.Push(5)
+	ExpectEqual(t, "front", 2, buf.front)
+	ExpectEqual(t, "back", 1, buf.back)
+
+	buf.Push(6)
+	ExpectEqual(t, "capacity", 8, buf.Capacity())
+	ExpectEqual(t, "size", 4, buf.Size())
+
+	for _, expected := range []int{3, 4, 5, 6} {
+		val, err := buf.Pop()
+		if err != nil {
+			t.Error(err)
+		}
+		ExpectEqual(t, "popped value", expected, val)
+	}
+}
+
 func ExpectEqual[T comparable](t *testing.T, name string, expected T, actual T) {
 	if actual != expected {
 		t.Errorf("expected %s to be %v, but it was %v", name, expected, actual)
@@ -18,6 +18,14 @@ const (
 	TokenTypeRightSquareBracket
 	TokenTypeQuestion
 	TokenTypeComma
+	TokenTypeLeftAngleBracket
+	TokenTypeRightAngleBracket
+	TokenTypeString
+	TokenTypeNumber
+	TokenTypeAt
+	TokenTypeEquals
+	TokenTypeComment
+	TokenTypeDocComment
 )
 
 func (tt TokenType) String() string {
@@ -40,6 +48,22 @@ func (tt TokenType) String() string {
 		return "?"
 	case TokenTypeComma:
 		return ","
+	case TokenTypeLeftAngleBracket:
+		return "<"
+	case TokenTypeRightAngleBracket:
+		return ">"
+	case TokenTypeString:
+		return "string"
+	case TokenTypeNumber:
+		return "number"
+	case TokenTypeAt:
+		return "@"
+	case TokenTypeEquals:
+		return "="
+	case TokenTypeComment:
+		return "comment"
+	case TokenTypeDocComment:
+		return "doc comment"
 	default:
 		panic("unknown token type")
 	}
@@ -51,6 +75,9 @@ type Token struct {
 	Text string
 	Span Span
 	Type TokenType
+	// Text of the doc comments (///) written directly before the token, with
+	// the leading slashes removed. Only set by the TokenStream.
+	Doc string
 }
 
 type Span struct {
@@ -85,6 +112,14 @@ func NewToken(text string, span Span) (Token, error) {
 		t.Type = TokenTypeRightSquareBracket
 	} else if text == "?" {
 		t.Type = TokenTypeQuestion
+	} else if text == "@" {
+		t.Type = TokenTypeAt
+	} else if text == "=" {
+		t.Type = TokenTypeEquals
+	} else if text == "<" {
+		t.Type = TokenTypeLeftAngleBracket
+	} else if text == ">" {
+		t.Type = TokenTypeRightAngleBracket
 	} else {
 		err := fmt.Errorf("%s is not a recognized token: %w", text, ErrInvalidToken)
 		return t, err
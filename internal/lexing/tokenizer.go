@@ -4,11 +4,24 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strings"
 	"unicode"
 )
 
 type Tokenizer struct {
 	source IRuneStream
+	errors []Error
+}
+
+// A problem found while splitting the source into tokens, like a character
+// which can't start one. The tokenizer carries on past it.
+type Error struct {
+	Span    Span
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("(%d:%d): %s", e.Span.Start.Line, e.Span.Start.Column, e.Message)
 }
 
 func NewTokenizer(input io.Reader) (*Tokenizer, error) {
@@ -20,6 +33,16 @@ func NewTokenizer(input io.Reader) (*Tokenizer, error) {
 	return &Tokenizer{source: rs}, nil
 }
 
+// returns the problems found in the tokens read so far
+func (t *Tokenizer) Errors() []Error {
+	return t.errors
+}
+
+// records a problem with the source between start and end
+func (t *Tokenizer) report(start Position, end Position, msg string) {
+	t.errors = append(t.errors, Error{Span: Span{Start: start, End: end}, Message: msg})
+}
+
 // returns the next token
 // the second value is true when at end of input
 func (t *Tokenizer) Next() (Token, bool) {
@@ -173,8 +196,239 @@ func (t *Tokenizer) Next() (Token, bool) {
 			}, err != nil
 		}
 
-		fmt.Printf("unrecognized character '%c'\n", t.source.Current())
-		t.source.Bump()
+		if t.source.Current() == '<' {
+			start := t.source.Position()
+			err := t.source.Bump()
+			return Token{
+				Type: TokenTypeLeftAngleBracket,
+				Text: "<",
+				Span: Span{
+					Start: start,
+					End:   start,
+				},
+			}, err != nil
+		}
+
+		if t.source.Current() == '>' {
+			start := t.source.Position()
+			err := t.source.Bump()
+			return Token{
+				Type: TokenTypeRightAngleBracket,
+				Text: ">",
+				Span: Span{
+					Start: start,
+					End:   start,
+				},
+			}, err != nil
+		}
+
+		if t.source.Current() == '"' {
+			return t.stringLiteral()
+		}
+
+		if unicode.IsDigit(t.source.Current()) || t.source.Current() == '-' {
+			return t.number()
+		}
+
+		if t.source.Current() == '@' {
+			start := t.source.Position()
+			err := t.source.Bump()
+			return Token{
+				Type: TokenTypeAt,
+				Text: "@",
+				Span: Span{
+					Start: start,
+					End:   start,
+				},
+			}, err != nil
+		}
+
+		if t.source.Current() == '=' {
+			start := t.source.Position()
+			err := t.source.Bump()
+			return Token{
+				Type: TokenTypeEquals,
+				Text: "=",
+				Span: Span{
+					Start: start,
+					End:   start,
+				},
+			}, err != nil
+		}
+
+		if t.source.Current() == '/' {
+			start := t.source.Position()
+			err := t.source.Bump()
+			if err != nil {
+				t.report(start, t.source.Position(), `unrecognized characters "/"`)
+				return Token{}, true
+			}
+
+			if t.source.Current() == '/' {
+				return t.lineComment(start)
+			}
+
+			if t.source.Current() == '*' {
+				return t.blockComment(start)
+			}
+
+			t.report(start, t.source.Position(), `unrecognized characters "/"`)
+			continue
+		}
+
+		if t.skipUnrecognized() {
+			return Token{}, true
+		}
+	}
+}
+
+// skips over a run of characters which can't start a token, reporting them
+// as one problem. returns true when at end of input.
+func (t *Tokenizer) skipUnrecognized() bool {
+	start := t.source.Position()
+	text := make([]rune, 0)
+	for {
+		text = append(text, t.source.Current())
+		err := t.source.Bump()
+		if err != nil || canStartToken(t.source.Current()) {
+			t.report(start, t.source.Position(), fmt.Sprintf("unrecognized characters \"%s\"", string(text)))
+			return err != nil
+		}
+	}
+}
+
+// returns whether r can start a token or a comment, or is whitespace between
+// them
+func canStartToken(r rune) bool {
+	return unicode.Is(unicode.White_Space, r) || unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_{}()[]?,<>\"-@=/", r)
+}
+
+// consumes a number: an optional minus sign followed by digits and decimal
+// points. the parser checks that they make up a valid number.
+func (t *Tokenizer) number() (Token, bool) {
+	start := t.source.Position()
+	text := []rune{t.source.Current()}
+	for {
+		err := t.source.Bump()
+		if err != nil {
+			return numberToken(text, start, t.source.Position()), true
+		}
+
+		if !unicode.IsDigit(t.source.Current()) && t.source.Current() != '.' {
+			return numberToken(text, start, t.source.Position()), false
+		}
+
+		text = append(text, t.source.Current())
+	}
+}
+
+func numberToken(text []rune, start Position, end Position) Token {
+	return Token{
+		Type: TokenTypeNumber,
+		Text: string(text),
+		Span: Span{
+			Start: start,
+			End:   end,
+		},
+	}
+}
+
+// consumes a double quoted string literal. the token text keeps the quotes
+// and escape sequences as written.
+func (t *Tokenizer) stringLiteral() (Token, bool) {
+	start := t.source.Position()
+	text := []rune{t.source.Current()}
+	escaped := false
+	for {
+		err := t.source.Bump()
+		if err != nil {
+			t.report(start, t.source.Position(), "unterminated string literal")
+			return stringToken(text, start, t.source.Position()), true
+		}
+
+		if t.source.Current() == '\n' {
+			t.report(start, t.source.Position(), "unterminated string literal")
+			return stringToken(text, start, t.source.Position()), false
+		}
+
+		text = append(text, t.source.Current())
+		if escaped {
+			escaped = false
+		} else if t.source.Current() == '\\' {
+			escaped = true
+		} else if t.source.Current() == '"' {
+			err := t.source.Bump()
+			return stringToken(text, start, t.source.Position()), err != nil
+		}
+	}
+}
+
+func stringToken(text []rune, start Position, end Position) Token {
+	return Token{
+		Type: TokenTypeString,
+		Text: string(text),
+		Span: Span{
+			Start: start,
+			End:   end,
+		},
+	}
+}
+
+// consumes a comment running to the end of the line. the opening '/' has
+// already been consumed and the source is at the second '/'. comments
+// starting with exactly three slashes are doc comments.
+func (t *Tokenizer) lineComment(start Position) (Token, bool) {
+	text := []rune{'/'}
+	for t.source.Current() != '\n' {
+		text = append(text, t.source.Current())
+		err := t.source.Bump()
+		if err != nil {
+			return lineCommentToken(text, start, t.source.Position()), true
+		}
+	}
+	return lineCommentToken(text, start, t.source.Position()), false
+}
+
+func lineCommentToken(text []rune, start Position, end Position) Token {
+	tok := commentToken(text, start, end)
+	if strings.HasPrefix(tok.Text, "///") && !strings.HasPrefix(tok.Text, "////") {
+		tok.Type = TokenTypeDocComment
+	}
+	return tok
+}
+
+// consumes a comment running up to and including the closing "*/". the
+// opening '/' has already been consumed and the source is at the '*'.
+func (t *Tokenizer) blockComment(start Position) (Token, bool) {
+	text := []rune{'/'}
+	prev := rune(0)
+	for {
+		text = append(text, t.source.Current())
+		closed := len(text) > 3 && prev == '*' && t.source.Current() == '/'
+		prev = t.source.Current()
+
+		err := t.source.Bump()
+		if err != nil {
+			if !closed {
+				t.report(start, t.source.Position(), "unterminated block comment")
+			}
+			return commentToken(text, start, t.source.Position()), true
+		}
+
+		if closed {
+			return commentToken(text, start, t.source.Position()), false
+		}
+	}
+}
+
+func commentToken(text []rune, start Position, end Position) Token {
+	return Token{
+		Type: TokenTypeComment,
+		Text: string(text),
+		Span: Span{
+			Start: start,
+			End:   end,
+		},
 	}
 }
 
@@ -36,3 +36,169 @@ func TestTokenizerTokenizes(t *testing.T) {
 	ExpectEqual(t, "token type", TokenTypeRightBracket, tok.Type)
 	ExpectEqual(t, "token text", "}", tok.Text)
 }
+
+func TestTokenizerTokenizesLineComment(t *testing.T) {
+	tokenizer, err := NewTokenizer(strings.NewReader("apples // the rest of the line\n}"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	tok, end := tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token text", "apples", tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token type", TokenTypeComment, tok.Type)
+	ExpectEqual(t, "token text", "// the rest of the line", tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", true, end)
+	ExpectEqual(t, "token type", TokenTypeRightBracket, tok.Type)
+}
+
+func TestTokenizerTokenizesBlockComment(t *testing.T) {
+	tokenizer, err := NewTokenizer(strings.NewReader("{ /* spans\n * lines **/ }"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	tok, end := tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token type", TokenTypeLeftBracket, tok.Type)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token type", TokenTypeComment, tok.Type)
+	ExpectEqual(t, "token text", "/* spans\n * lines **/", tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", true, end)
+	ExpectEqual(t, "token type", TokenTypeRightBracket, tok.Type)
+}
+
+func TestTokenizerTokenizesCommentAtEndOfInput(t *testing.T) {
+	tokenizer, err := NewTokenizer(strings.NewReader("apples /**/"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	tok, end := tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token text", "apples", tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", true, end)
+	ExpectEqual(t, "token type", TokenTypeComment, tok.Type)
+	ExpectEqual(t, "token text", "/**/", tok.Text)
+}
+
+func TestTokenizerTokenizesDocComment(t *testing.T) {
+	tokenizer, err := NewTokenizer(strings.NewReader("/// documented\n//// not documented\n}"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	tok, end := tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token type", TokenTypeDocComment, tok.Type)
+	ExpectEqual(t, "token text", "/// documented", tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token type", TokenTypeComment, tok.Type)
+	ExpectEqual(t, "token text", "//// not documented", tok.Text)
+}
+
+func TestTokenizerTokenizesStringLiteral(t *testing.T) {
+	tokenizer, err := NewTokenizer(strings.NewReader(`import "dir/common.rpc" "say \"hi\""`))
+	if err != nil {
+		t.Error(err)
+	}
+
+	tok, end := tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token text", "import", tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token type", TokenTypeString, tok.Type)
+	ExpectEqual(t, "token text", `"dir/common.rpc"`, tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", true, end)
+	ExpectEqual(t, "token type", TokenTypeString, tok.Type)
+	ExpectEqual(t, "token text", `"say \"hi\""`, tok.Text)
+}
+
+func TestTokenizerTokenizesAnnotation(t *testing.T) {
+	tokenizer, err := NewTokenizer(strings.NewReader(`@range(-1.5, 20)`))
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []struct {
+		ty   TokenType
+		text string
+	}{
+		{TokenTypeAt, "@"},
+		{TokenTypeIdentifier, "range"},
+		{TokenTypeLeftParenthesis, "("},
+		{TokenTypeNumber, "-1.5"},
+		{TokenTypeComma, ","},
+		{TokenTypeNumber, "20"},
+		{TokenTypeRightParenthesis, ")"},
+	}
+
+	for idx, e := range expected {
+		tok, end := tokenizer.Next()
+		ExpectEqual(t, "end", idx == len(expected)-1, end)
+		ExpectEqual(t, "token type", e.ty, tok.Type)
+		ExpectEqual(t, "token text", e.text, tok.Text)
+	}
+}
+
+func TestTokenizerSkipsUnrecognizedCharacterAtEnd(t *testing.T) {
+	tokenizer, err := NewTokenizer(strings.NewReader("apples $"))
+	if err != nil {
+		t.Error(err)
+	}
+
+	tok, end := tokenizer.Next()
+	ExpectEqual(t, "end", false, end)
+	ExpectEqual(t, "token text", "apples", tok.Text)
+
+	tok, end = tokenizer.Next()
+	ExpectEqual(t, "end", true, end)
+	ExpectEqual(t, "token text", "", tok.Text)
+}
+
+func TestTokenizerReportsProblems(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{"apples $%", `(0:7): unrecognized characters "$%"`},
+		{"apples / }", `(0:7): unrecognized characters "/"`},
+		{"apples /", `(0:7): unrecognized characters "/"`},
+		{"apples /* never closed\n}", "(0:7): unterminated block comment"},
+		{"apples \"never closed\n}", "(0:7): unterminated string literal"},
+	}
+
+	for _, c := range cases {
+		tokenizer, err := NewTokenizer(strings.NewReader(c.source))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for end := false; !end; {
+			_, end = tokenizer.Next()
+		}
+
+		errors := tokenizer.Errors()
+		ExpectEqual(t, "error count", 1, len(errors))
+		if len(errors) == 1 {
+			ExpectEqual(t, "error", c.expected, errors[0].Error())
+		}
+	}
+}
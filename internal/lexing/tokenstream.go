@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 var ErrEndOfStream = errors.New("end of token stream")
@@ -41,7 +42,7 @@ func (t *TokenStream) Next() (Token, error) {
 		return Token{}, ErrEndOfStream
 	}
 
-	tok, end := t.tokenizer.Next()
+	tok, end := t.read()
 	if end {
 		t.end = true
 	}
@@ -54,7 +55,7 @@ func (t *TokenStream) Lookahead(n int) (Token, error) {
 			return Token{}, ErrEndOfStream
 		}
 
-		tok, end := t.tokenizer.Next()
+		tok, end := t.read()
 		if end {
 			t.end = true
 		}
@@ -72,3 +73,28 @@ func (t *TokenStream) Lookahead(n int) (Token, error) {
 
 	return tok, nil
 }
+
+// returns the problems the tokenizer found in the tokens read so far
+func (t *TokenStream) Errors() []Error {
+	return t.tokenizer.Errors()
+}
+
+// reads the next token from the tokenizer, skipping over comments. doc
+// comments are attached to the token that follows them.
+func (t *TokenStream) read() (Token, bool) {
+	doc := make([]string, 0)
+	for {
+		tok, end := t.tokenizer.Next()
+		if tok.Type == TokenTypeDocComment {
+			line := strings.TrimPrefix(tok.Text, "///")
+			line = strings.TrimPrefix(line, " ")
+			doc = append(doc, strings.TrimRight(line, " \t\r"))
+		} else if tok.Type != TokenTypeComment {
+			tok.Doc = strings.Join(doc, "\n")
+			return tok, end
+		}
+		if end {
+			return Token{}, true
+		}
+	}
+}
@@ -60,3 +60,56 @@ func TestTokenStreamLookaheadReturnsToken(t *testing.T) {
 	ExpectEqual(t, "token text", "something2", tok.Text)
 	ExpectEqual(t, "token type", TokenTypeIdentifier, tok.Type)
 }
+
+func TestTokenStreamSkipsComments(t *testing.T) {
+	ts, err := NewTokenStream(strings.NewReader("// leading\nsomething /* inner */ something2 // trailing"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tok, err := ts.Lookahead(1)
+	if err != nil {
+		t.Error(err)
+	}
+	ExpectEqual(t, "token text", "something2", tok.Text)
+
+	tok, err = ts.Next()
+	if err != nil {
+		t.Error(err)
+	}
+	ExpectEqual(t, "token text", "something", tok.Text)
+
+	tok, err = ts.Next()
+	if err != nil {
+		t.Error(err)
+	}
+	ExpectEqual(t, "token text", "something2", tok.Text)
+
+	_, err = ts.Lookahead(0)
+	if !errors.Is(err, ErrEndOfStream) {
+		t.Error(err)
+	}
+}
+
+func TestTokenStreamAttachesDocComments(t *testing.T) {
+	ts, err := NewTokenStream(strings.NewReader("/// first line\n///second line\n// plain\nsomething something2"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tok, err := ts.Next()
+	if err != nil {
+		t.Error(err)
+	}
+	ExpectEqual(t, "token text", "something", tok.Text)
+	ExpectEqual(t, "token doc", "first line\nsecond line", tok.Doc)
+
+	tok, err = ts.Next()
+	if err != nil {
+		t.Error(err)
+	}
+	ExpectEqual(t, "token text", "something2", tok.Text)
+	ExpectEqual(t, "token doc", "", tok.Doc)
+}
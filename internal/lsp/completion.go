@@ -0,0 +1,57 @@
+package lsp
+
+import (
+	"github.com/fireland15/rpc-gen/internal/lexing"
+	"github.com/fireland15/rpc-gen/internal/parser"
+	"github.com/fireland15/rpc-gen/internal/syntax"
+)
+
+// Completes the built-in types and the declared models, enums and unions, or
+// the declared errors after throws.
+func (s *server) completion(params textDocumentPositionParams) []completionItem {
+	items := make([]completionItem, 0)
+	snap := newSnapshot(s.documents)
+	doc := s.document(snap, params.TextDocument.URI)
+	if doc == nil {
+		return items
+	}
+
+	// the identifier being typed doesn't say what's expected, so look past it
+	p := doc.sourcePosition(params.Position)
+	tok, path := doc.tokenBefore(p)
+	if tok != nil && tok.Type == lexing.TokenTypeIdentifier && !before(tok.Span.End, p) {
+		tok, path = doc.tokenBefore(tok.Span.Start)
+	}
+	throws := tok != nil && path[len(path)-1].Kind == syntax.NodeThrows &&
+		(tok.Text == string(parser.KwThrows) || tok.Type == lexing.TokenTypeComma)
+
+	if !throws {
+		for _, name := range builtins {
+			items = append(items, completionItem{Label: name, Kind: completionKindKeyword, Detail: "built-in"})
+		}
+		items = append(items, completionItem{Label: string(parser.KwMap), Kind: completionKindKeyword, Detail: "built-in"})
+	}
+
+	for _, decl := range snap.declarations(doc) {
+		if throws != (decl.node.Kind == syntax.NodeError) {
+			continue
+		}
+
+		item := completionItem{Label: decl.name.Text, Detail: decl.node.Kind.String()}
+		switch decl.node.Kind {
+		case syntax.NodeModel:
+			item.Kind = completionKindStruct
+		case syntax.NodeEnum:
+			item.Kind = completionKindEnum
+		case syntax.NodeUnion:
+			item.Kind = completionKindInterface
+		case syntax.NodeError:
+			item.Kind = completionKindClass
+		}
+		if comment := docOf(decl.node); comment != "" {
+			item.Documentation = &markupContent{Kind: "markdown", Value: comment}
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,56 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Reads and writes JSON-RPC messages, each preceded by a Content-Length
+// header.
+type conn struct {
+	reader *textproto.Reader
+	out    io.Writer
+}
+
+func newConn(in io.Reader, out io.Writer) *conn {
+	return &conn{reader: textproto.NewReader(bufio.NewReader(in)), out: out}
+}
+
+// Reads the body of the next message. io.EOF is returned when the input ends
+// between messages.
+func (c *conn) read() ([]byte, error) {
+	header, err := c.reader.ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("problem reading message header: %w", err)
+	}
+
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length \"%s\"", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(c.reader.R, body)
+	if err != nil {
+		return nil, fmt.Errorf("problem reading message body: %w", err)
+	}
+	return body, nil
+}
+
+func (c *conn) write(message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
@@ -0,0 +1,166 @@
+package lsp
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/analysis"
+	"github.com/fireland15/rpc-gen/internal/compiler"
+	"github.com/fireland15/rpc-gen/internal/lexing"
+	"github.com/fireland15/rpc-gen/internal/syntax"
+)
+
+// Matches the "file:line:column: " which messages about a declaration start
+// with.
+var sourcePrefix = regexp.MustCompile(`^(.+?):(\d+):(\d+): `)
+
+// Returns the syntax errors in the document or, when there aren't any, the
+// errors found by analysing it along with the files it imports. Errors in the
+// imported files are left to be reported in those files.
+func (s *server) diagnose(doc *document) []diagnostic {
+	diagnostics := make([]diagnostic, 0)
+	for _, e := range doc.tree.Errors {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    doc.rangeOf(e.Span),
+			Severity: severityError,
+			Source:   "rpc-gen",
+			Message:  e.Message,
+		})
+	}
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+
+	service, err := compiler.LoadFrom(doc.path, s.openFile)
+	if err != nil {
+		// loading fails on the imports, which are in the document
+		d, _ := doc.diagnostic(err.Error())
+		if errors.Is(err, compiler.ErrImportCycle) {
+			// which doesn't say where, so it's put on the import leading to it
+			d.Range = doc.rangeOf(s.cycleSpan(doc, err.Error()))
+		}
+		return append(diagnostics, d)
+	}
+
+	for _, msg := range analysis.Check(&service) {
+		if d, ok := doc.diagnostic(msg); ok {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+// Returns the span of the document's import which leads to the import cycle
+// described by msg, or of its first import when none of them can be found.
+func (s *server) cycleSpan(doc *document, msg string) lexing.Span {
+	cycle := make([]string, 0)
+	paths := strings.TrimPrefix(msg, compiler.ErrImportCycle.Error()+": ")
+	for _, path := range strings.Split(paths, " -> ") {
+		if absPath, err := filepath.Abs(path); err == nil {
+			cycle = append(cycle, absPath)
+		}
+	}
+
+	snap := newSnapshot(s.documents)
+	imports := doc.tree.NodesOf(syntax.NodeImport)
+	for _, imp := range imports {
+		path, ok := doc.importPath(imp)
+		if !ok {
+			continue
+		}
+
+		imported := snap.document(path)
+		if imported == nil {
+			continue
+		}
+		for _, d := range snap.scope(imported) {
+			if slices.Contains(cycle, d.path) {
+				return imp.Tokens()[0].Span
+			}
+		}
+	}
+
+	if len(imports) > 0 {
+		return imports[0].Tokens()[0].Span
+	}
+	return lexing.Span{}
+}
+
+// Opens the file at path, reading open documents from the editor rather than
+// from disk.
+func (s *server) openFile(path string) (io.ReadCloser, error) {
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		if doc, ok := s.documents[absPath]; ok {
+			return io.NopCloser(strings.NewReader(doc.text)), nil
+		}
+	}
+	return os.Open(path)
+}
+
+// Turns an error message into a diagnostic at the declaration or member it
+// names. The diagnostic is at the start of the document when the message
+// doesn't name one, and false is returned when it names one in a different
+// file.
+func (doc *document) diagnostic(msg string) (diagnostic, bool) {
+	d := diagnostic{Severity: severityError, Source: "rpc-gen", Message: msg}
+
+	match := sourcePrefix.FindStringSubmatch(msg)
+	if match == nil {
+		return d, true
+	}
+
+	path, err := filepath.Abs(match[1])
+	if err != nil || path != doc.path {
+		return d, false
+	}
+
+	line, _ := strconv.Atoi(match[2])
+	column, _ := strconv.Atoi(match[3])
+	d.Range = doc.rangeOf(doc.declarationSpan(lexing.Position{Line: line - 1, Column: column - 1}))
+	d.Message = msg[len(match[0]):]
+	return d, true
+}
+
+// Returns the span of the name of the declaration, field or parameter
+// starting at p, or of the token there when it doesn't have a name.
+func (doc *document) declarationSpan(p lexing.Position) lexing.Span {
+	var span *lexing.Span
+
+	var walk func(n *syntax.Node)
+	walk = func(n *syntax.Node) {
+		for _, child := range n.Children {
+			switch c := child.(type) {
+			case *syntax.Token:
+				if span == nil && c.Span.Start.Line == p.Line && c.Span.Start.Column == p.Column {
+					span = &c.Span
+				}
+			case *syntax.Node:
+				tokens := c.AllTokens()
+				if len(tokens) == 0 || before(p, tokens[0].Span.Start) || !before(p, tokens[len(tokens)-1].Span.End) {
+					continue
+				}
+				if name, ok := c.Name(); ok && tokens[0].Span.Start.Line == p.Line && tokens[0].Span.Start.Column == p.Column {
+					span = &name.Span
+					return
+				}
+				walk(c)
+			}
+			if span != nil {
+				return
+			}
+		}
+	}
+	walk(doc.tree.Node)
+
+	if span == nil {
+		return lexing.Span{Start: p, End: p}
+	}
+	return *span
+}
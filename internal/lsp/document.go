@@ -0,0 +1,295 @@
+package lsp
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/fireland15/rpc-gen/internal/lexing"
+	"github.com/fireland15/rpc-gen/internal/parser"
+	"github.com/fireland15/rpc-gen/internal/syntax"
+)
+
+// A definition file, either open in the editor or read from disk.
+type document struct {
+	uri string
+	// The cleaned absolute path, which identifies the document.
+	path    string
+	version int
+	text    string
+	lines   []string
+	tree    *syntax.File
+}
+
+func newDocument(path string, version int, text string) *document {
+	return &document{
+		uri:     pathToURI(path),
+		path:    path,
+		version: version,
+		text:    text,
+		lines:   strings.Split(text, "\n"),
+		tree:    syntax.Parse(text),
+	}
+}
+
+func uriToPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+
+	path := filepath.FromSlash(u.Path)
+	if filepath.VolumeName(path[min(1, len(path)):]) != "" {
+		// a windows path such as /C:/definitions/journal.rpc
+		path = path[1:]
+	}
+	return filepath.Clean(path), true
+}
+
+func pathToURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// Converts a position in the source, whose column counts runes, to a protocol
+// position, whose character counts UTF-16 code units.
+func (d *document) position(p lexing.Position) position {
+	if p.Line >= len(d.lines) {
+		return position{Line: p.Line, Character: p.Column}
+	}
+
+	character := 0
+	column := 0
+	for _, r := range d.lines[p.Line] {
+		if column == p.Column {
+			break
+		}
+		character += len(utf16.Encode([]rune{r}))
+		column++
+	}
+	return position{Line: p.Line, Character: character}
+}
+
+func (d *document) rangeOf(span lexing.Span) textRange {
+	return textRange{Start: d.position(span.Start), End: d.position(span.End)}
+}
+
+// Converts a protocol position to a source position. Only the line and column
+// are filled in.
+func (d *document) sourcePosition(p position) lexing.Position {
+	if p.Line >= len(d.lines) {
+		return lexing.Position{Line: p.Line, Column: p.Character}
+	}
+
+	character := 0
+	column := 0
+	for _, r := range d.lines[p.Line] {
+		character += len(utf16.Encode([]rune{r}))
+		if character > p.Character {
+			break
+		}
+		column++
+	}
+	return lexing.Position{Line: p.Line, Column: column}
+}
+
+// Returns the token at p along with the nodes it's in, outermost first.
+// Identifiers are preferred when p is between two tokens.
+func (d *document) tokenAt(p lexing.Position) (*syntax.Token, []*syntax.Node) {
+	var found *syntax.Token
+	var foundPath []*syntax.Node
+	walkTokens(d.tree.Node, nil, func(tok *syntax.Token, path []*syntax.Node) {
+		if !before(p, tok.Span.Start) && !before(tok.Span.End, p) && (found == nil || tok.Type == lexing.TokenTypeIdentifier) {
+			found = tok
+			foundPath = slices.Clone(path)
+		}
+	})
+	return found, foundPath
+}
+
+// Returns the last token which ends at or before p, along with the nodes it's
+// in, outermost first.
+func (d *document) tokenBefore(p lexing.Position) (*syntax.Token, []*syntax.Node) {
+	var found *syntax.Token
+	var foundPath []*syntax.Node
+	walkTokens(d.tree.Node, nil, func(tok *syntax.Token, path []*syntax.Node) {
+		if !before(p, tok.Span.End) {
+			found = tok
+			foundPath = slices.Clone(path)
+		}
+	})
+	return found, foundPath
+}
+
+// Returns the paths of the files the document imports.
+func (d *document) imports() []string {
+	paths := make([]string, 0)
+	for _, imp := range d.tree.NodesOf(syntax.NodeImport) {
+		if path, ok := d.importPath(imp); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Returns the path of the file an import node imports.
+func (d *document) importPath(imp *syntax.Node) (string, bool) {
+	for _, tok := range imp.Tokens() {
+		if tok.Type != lexing.TokenTypeString {
+			continue
+		}
+
+		path, err := strconv.Unquote(tok.Text)
+		if err != nil {
+			return "", false
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(d.path), path)
+		}
+		return filepath.Clean(path), true
+	}
+	return "", false
+}
+
+func before(a lexing.Position, b lexing.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
+
+// The documents used to answer a request: the open documents, and the files
+// read from disk, which are only read once.
+type snapshot struct {
+	open map[string]*document
+	read map[string]*document
+}
+
+func newSnapshot(open map[string]*document) *snapshot {
+	return &snapshot{open: open, read: make(map[string]*document)}
+}
+
+// Returns the document at path, or nil when it can't be read.
+func (s *snapshot) document(path string) *document {
+	if doc, ok := s.open[path]; ok {
+		return doc
+	}
+	if doc, ok := s.read[path]; ok {
+		return doc
+	}
+
+	var doc *document
+	source, err := os.ReadFile(path)
+	if err == nil {
+		doc = newDocument(path, 0, string(source))
+	}
+	s.read[path] = doc
+	return doc
+}
+
+// Returns the document and the documents it imports, directly or not.
+func (s *snapshot) scope(doc *document) []*document {
+	scope := []*document{doc}
+	for idx := 0; idx < len(scope); idx++ {
+		for _, path := range scope[idx].imports() {
+			imported := s.document(path)
+			if imported != nil && !slices.Contains(scope, imported) {
+				scope = append(scope, imported)
+			}
+		}
+	}
+	return scope
+}
+
+// A model, enum, union or error.
+type declaration struct {
+	doc  *document
+	node *syntax.Node
+	name *syntax.Token
+}
+
+// Finds the declaration named name which is visible from doc.
+func (s *snapshot) resolve(doc *document, name string) (declaration, bool) {
+	for _, d := range s.declarations(doc) {
+		if d.name.Text == name {
+			return d, true
+		}
+	}
+	return declaration{}, false
+}
+
+// Returns the declarations visible from doc.
+func (s *snapshot) declarations(doc *document) []declaration {
+	declarations := make([]declaration, 0)
+	for _, d := range s.scope(doc) {
+		for _, node := range d.tree.Nodes() {
+			if !isTypeDeclaration(node) {
+				continue
+			}
+			if tok, ok := node.Name(); ok {
+				declarations = append(declarations, declaration{doc: d, node: node, name: tok})
+			}
+		}
+	}
+	return declarations
+}
+
+func isTypeDeclaration(node *syntax.Node) bool {
+	switch node.Kind {
+	case syntax.NodeModel, syntax.NodeEnum, syntax.NodeUnion, syntax.NodeError:
+		return true
+	}
+	return false
+}
+
+// Returns the name of the declaration which tok refers to or declares, given
+// the nodes it's in.
+func declarationName(tok *syntax.Token, path []*syntax.Node) (string, bool) {
+	if tok == nil || tok.Type != lexing.TokenTypeIdentifier || len(path) == 0 {
+		return "", false
+	}
+
+	parent := path[len(path)-1]
+	switch parent.Kind {
+	case syntax.NodeType:
+		if typeName(parent) == tok && !isBuiltin(parent) {
+			return tok.Text, true
+		}
+	case syntax.NodeThrows:
+		if parent.Tokens()[0] != tok {
+			return tok.Text, true
+		}
+	case syntax.NodeModel, syntax.NodeEnum, syntax.NodeUnion, syntax.NodeError:
+		if name, ok := parent.Name(); ok && name == tok {
+			return tok.Text, true
+		}
+	}
+	return "", false
+}
+
+// Returns the identifier naming a type node's type, e.g. Entry in Entry[]?.
+func typeName(n *syntax.Node) *syntax.Token {
+	tokens := n.Tokens()
+	if len(tokens) == 0 || tokens[0].Type != lexing.TokenTypeIdentifier {
+		return nil
+	}
+	return tokens[0]
+}
+
+var builtins = []string{"bool", "int", "float", "string", "uuid", "date"}
+
+func isBuiltin(n *syntax.Node) bool {
+	name := typeName(n)
+	if name == nil {
+		return false
+	}
+	if name.Text == string(parser.KwMap) {
+		tokens := n.Tokens()
+		return len(tokens) > 1 && tokens[1].Type == lexing.TokenTypeLeftAngleBracket
+	}
+	return slices.Contains(builtins, name.Text)
+}
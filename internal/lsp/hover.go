@@ -0,0 +1,151 @@
+package lsp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/lexing"
+	"github.com/fireland15/rpc-gen/internal/syntax"
+)
+
+var builtinDocs = map[string]string{
+	"bool":   "A built-in type holding true or false.",
+	"int":    "A built-in type holding an integer.",
+	"float":  "A built-in type holding a floating point number.",
+	"string": "A built-in type holding text.",
+	"uuid":   "A built-in type holding a UUID.",
+	"date":   "A built-in type holding a date and time.",
+	"map":    "A built-in type mapping keys to values, written `map<K, V>`. The key type must be `string`, `int`, `uuid` or an enum.",
+}
+
+// Describes the identifier at the position. Types are shown with the
+// declaration they resolve to, and fields, parameters and variants with the
+// declarations of their types.
+func (s *server) hover(params textDocumentPositionParams) *hover {
+	snap := newSnapshot(s.documents)
+	doc := s.document(snap, params.TextDocument.URI)
+	if doc == nil {
+		return nil
+	}
+
+	tok, path := doc.tokenAt(doc.sourcePosition(params.Position))
+	if tok == nil || tok.Type != lexing.TokenTypeIdentifier {
+		return nil
+	}
+
+	parent := path[len(path)-1]
+	sections := make([]string, 0)
+	if name, ok := declarationName(tok, path); ok {
+		if decl, ok := snap.resolve(doc, name); ok {
+			sections = declarationHover(decl.node)
+		}
+	} else if parent.Kind == syntax.NodeType && typeName(parent) == tok && isBuiltin(parent) {
+		sections = append(sections, code(tok.Text), builtinDocs[tok.Text])
+	} else if name, ok := parent.Name(); ok && name == tok {
+		switch parent.Kind {
+		case syntax.NodeField, syntax.NodeParameter, syntax.NodeVariant:
+			sections = append(sections, code(memberText(parent)))
+			if comment := docOf(parent); comment != "" {
+				sections = append(sections, comment)
+			}
+			for _, name := range typeNames(parent) {
+				if decl, ok := snap.resolve(doc, name); ok {
+					sections = append(sections, declarationHover(decl.node)...)
+				}
+			}
+		case syntax.NodeEnumValue:
+			value := tok.Text
+			if enumName, ok := path[len(path)-2].Name(); ok {
+				value = fmt.Sprintf("%s.%s", enumName.Text, tok.Text)
+			}
+			sections = append(sections, code(value))
+			if comment := docOf(parent); comment != "" {
+				sections = append(sections, comment)
+			}
+		case syntax.NodeRpc, syntax.NodeService:
+			sections = declarationHover(parent)
+		}
+	}
+
+	if len(sections) == 0 {
+		return nil
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: strings.Join(sections, "\n\n")},
+		Range:    doc.rangeOf(tok.Span),
+	}
+}
+
+// Returns the formatted declaration and its doc comment.
+func declarationHover(n *syntax.Node) []string {
+	text := n.Text()
+	if formatted, err := syntax.Format(text); err == nil {
+		text = strings.TrimSuffix(formatted, "\n")
+	}
+
+	sections := []string{code(text)}
+	if comment := docOf(n); comment != "" {
+		sections = append(sections, comment)
+	}
+	return sections
+}
+
+// Returns a field, parameter or variant without its annotations, e.g.
+// `title string = "untitled"`.
+func memberText(n *syntax.Node) string {
+	parts := make([]string, 0)
+	if name, ok := n.Name(); ok {
+		parts = append(parts, name.Text)
+	}
+	if typ, ok := n.NodeOf(syntax.NodeType); ok {
+		parts = append(parts, typ.Text())
+	}
+	if value, ok := n.NodeOf(syntax.NodeDefault); ok {
+		parts = append(parts, value.Text())
+	}
+	return strings.Join(parts, " ")
+}
+
+// Returns the names of the declarations used in the type of a field,
+// parameter or variant, e.g. Status and Entry in map<Status, Entry[]>.
+func typeNames(n *syntax.Node) []string {
+	names := make([]string, 0)
+	var visit func(typ *syntax.Node)
+	visit = func(typ *syntax.Node) {
+		if name := typeName(typ); name != nil && !isBuiltin(typ) && !slices.Contains(names, name.Text) {
+			names = append(names, name.Text)
+		}
+		for _, inner := range typ.NodesOf(syntax.NodeType) {
+			visit(inner)
+		}
+	}
+
+	if typ, ok := n.NodeOf(syntax.NodeType); ok {
+		visit(typ)
+	}
+	return names
+}
+
+// Returns the doc comment written before the node, read the way the parser
+// reads it.
+func docOf(n *syntax.Node) string {
+	tokens := n.AllTokens()
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0)
+	for _, trivia := range tokens[0].Leading {
+		if trivia.Kind == syntax.TriviaDocComment {
+			line := strings.TrimPrefix(trivia.Text, "///")
+			line = strings.TrimPrefix(line, " ")
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func code(text string) string {
+	return fmt.Sprintf("```rpc\n%s\n```", text)
+}
@@ -0,0 +1,103 @@
+package lsp
+
+import (
+	"slices"
+
+	"github.com/fireland15/rpc-gen/internal/syntax"
+)
+
+// Returns the declaration of the model, enum, union or error at the position.
+func (s *server) definition(params textDocumentPositionParams) []location {
+	snap := newSnapshot(s.documents)
+	decl, ok := s.declarationAt(snap, params)
+	if !ok {
+		return nil
+	}
+	return []location{{URI: decl.doc.uri, Range: decl.doc.rangeOf(decl.name.Span)}}
+}
+
+// Returns the references to the model, enum, union or error at the position,
+// from every definition file in the workspace which can see its declaration.
+func (s *server) references(params referenceParams) []location {
+	locations := make([]location, 0)
+	snap := newSnapshot(s.documents)
+	decl, ok := s.declarationAt(snap, params.textDocumentPositionParams)
+	if !ok {
+		return locations
+	}
+
+	docs := make([]*document, 0)
+	add := func(doc *document) {
+		if doc != nil && !slices.Contains(docs, doc) {
+			docs = append(docs, doc)
+		}
+	}
+	for _, doc := range snap.scope(s.document(snap, params.TextDocument.URI)) {
+		add(doc)
+	}
+	for _, path := range s.workspaceFiles() {
+		add(snap.document(path))
+	}
+	for _, doc := range s.documents {
+		add(doc)
+	}
+	slices.SortFunc(docs, func(a, b *document) int {
+		if a.path < b.path {
+			return -1
+		} else if a.path > b.path {
+			return 1
+		}
+		return 0
+	})
+
+	for _, doc := range docs {
+		if resolved, ok := snap.resolve(doc, decl.name.Text); !ok || resolved.name != decl.name {
+			continue
+		}
+
+		walkTokens(doc.tree.Node, nil, func(tok *syntax.Token, path []*syntax.Node) {
+			name, ok := declarationName(tok, path)
+			if !ok || name != decl.name.Text {
+				return
+			}
+			if tok == decl.name && !params.Context.IncludeDeclaration {
+				return
+			}
+			if tok != decl.name && isTypeDeclaration(path[len(path)-1]) {
+				// a duplicate declaration
+				return
+			}
+			locations = append(locations, location{URI: doc.uri, Range: doc.rangeOf(tok.Span)})
+		})
+	}
+	return locations
+}
+
+// Finds the declaration which the identifier at the position refers to or
+// declares.
+func (s *server) declarationAt(snap *snapshot, params textDocumentPositionParams) (declaration, bool) {
+	doc := s.document(snap, params.TextDocument.URI)
+	if doc == nil {
+		return declaration{}, false
+	}
+
+	tok, path := doc.tokenAt(doc.sourcePosition(params.Position))
+	name, ok := declarationName(tok, path)
+	if !ok {
+		return declaration{}, false
+	}
+	return snap.resolve(doc, name)
+}
+
+// Calls visit with each token in n and the nodes it's in, outermost first.
+func walkTokens(n *syntax.Node, path []*syntax.Node, visit func(tok *syntax.Token, path []*syntax.Node)) {
+	path = append(path, n)
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *syntax.Token:
+			visit(c, path)
+		case *syntax.Node:
+			walkTokens(c, path, visit)
+		}
+	}
+}
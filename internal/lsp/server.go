@@ -0,0 +1,279 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var ErrNoShutdown = errors.New("the client exited without shutting down the server")
+
+type server struct {
+	conn *conn
+	// The open documents by path.
+	documents map[string]*document
+	// The workspace folders, searched for references.
+	roots        []string
+	initialized  bool
+	shuttingDown bool
+	// The first error writing to the client.
+	writeErr error
+}
+
+// Serves the language server protocol over in and out until the client exits.
+// ErrNoShutdown is returned when the client exits, or the input ends, without
+// shutting the server down first.
+func Serve(in io.Reader, out io.Writer) error {
+	s := &server{conn: newConn(in, out), documents: make(map[string]*document)}
+	for {
+		body, err := s.conn.read()
+		if err == io.EOF {
+			if s.shuttingDown {
+				return nil
+			}
+			return ErrNoShutdown
+		}
+		if err != nil {
+			return err
+		}
+
+		req := request{}
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			s.reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()})
+		} else if req.Method == "exit" {
+			if s.shuttingDown {
+				return nil
+			}
+			return ErrNoShutdown
+		} else {
+			result, rerr := s.handle(req)
+			if req.ID != nil {
+				s.reply(req.ID, result, rerr)
+			}
+		}
+
+		if s.writeErr != nil {
+			return s.writeErr
+		}
+	}
+}
+
+func (s *server) handle(req request) (any, *responseError) {
+	if !s.initialized && req.Method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "the server hasn't been initialized"}
+	}
+	if s.shuttingDown {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "the server is shutting down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		params := initializeParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(params), nil
+	case "shutdown":
+		s.shuttingDown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		params := didOpenParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+	case "textDocument/didChange":
+		params := didChangeParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) > 0 {
+			text := params.ContentChanges[len(params.ContentChanges)-1].Text
+			s.open(params.TextDocument.URI, params.TextDocument.Version, text)
+		}
+	case "textDocument/didSave":
+		// files which aren't open may import the saved one
+		s.publishDiagnostics()
+	case "textDocument/didClose":
+		params := didCloseParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		s.close(params.TextDocument.URI)
+	case "textDocument/definition":
+		params := textDocumentPositionParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params), nil
+	case "textDocument/references":
+		params := referenceParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.references(params), nil
+	case "textDocument/hover":
+		params := textDocumentPositionParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params), nil
+	case "textDocument/completion":
+		params := textDocumentPositionParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	case "textDocument/documentSymbol":
+		params := documentSymbolParams{}
+		if err := decode(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.documentSymbols(params), nil
+	default:
+		if req.ID != nil && !strings.HasPrefix(req.Method, "$/") {
+			return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("unsupported method \"%s\"", req.Method)}
+		}
+	}
+	return nil, nil
+}
+
+func decode(params json.RawMessage, v any) *responseError {
+	err := json.Unmarshal(params, v)
+	if err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *server) initialize(params initializeParams) initializeResult {
+	s.initialized = true
+	for _, folder := range params.WorkspaceFolders {
+		if path, ok := uriToPath(folder.URI); ok {
+			s.roots = append(s.roots, path)
+		}
+	}
+	if len(s.roots) == 0 {
+		if path, ok := uriToPath(params.RootURI); ok {
+			s.roots = append(s.roots, path)
+		}
+	}
+
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync:       textDocumentSyncOptions{OpenClose: true, Change: textDocumentSyncFull, Save: true},
+			DefinitionProvider:     true,
+			ReferencesProvider:     true,
+			HoverProvider:          true,
+			CompletionProvider:     completionOptions{TriggerCharacters: []string{"<", ","}},
+			DocumentSymbolProvider: true,
+		},
+		ServerInfo: serverInfo{Name: "rpc-gen"},
+	}
+}
+
+func (s *server) open(uri string, version int, text string) {
+	path, ok := uriToPath(uri)
+	if !ok {
+		return
+	}
+
+	doc := newDocument(path, version, text)
+	// keep the client's spelling of the uri for the diagnostics
+	doc.uri = uri
+	s.documents[path] = doc
+	s.publishDiagnostics()
+}
+
+func (s *server) close(uri string) {
+	path, ok := uriToPath(uri)
+	if !ok {
+		return
+	}
+
+	delete(s.documents, path)
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: make([]diagnostic, 0)})
+	s.publishDiagnostics()
+}
+
+// Publishes the diagnostics of every open document, since a change to one can
+// affect those importing it.
+func (s *server) publishDiagnostics() {
+	paths := make([]string, 0, len(s.documents))
+	for path := range s.documents {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		doc := s.documents[path]
+		version := doc.version
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         doc.uri,
+			Version:     &version,
+			Diagnostics: s.diagnose(doc),
+		})
+	}
+}
+
+// Returns the document with the uri, reading it from disk when it isn't open.
+func (s *server) document(snap *snapshot, uri string) *document {
+	path, ok := uriToPath(uri)
+	if !ok {
+		return nil
+	}
+	return snap.document(path)
+}
+
+// Returns the paths of the definition files in the workspace folders.
+func (s *server) workspaceFiles() []string {
+	files := make([]string, 0)
+	for _, root := range s.roots {
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if path != root && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(path) == ".rpc" {
+				files = append(files, filepath.Clean(path))
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func (s *server) reply(id json.RawMessage, result any, rerr *responseError) {
+	res := response{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		body, err := json.Marshal(result)
+		if err != nil {
+			res.Error = &responseError{Code: codeInternalError, Message: err.Error()}
+		} else {
+			res.Result = body
+		}
+	}
+	s.write(res)
+}
+
+func (s *server) notify(method string, params any) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *server) write(message any) {
+	if s.writeErr != nil {
+		return
+	}
+	s.writeErr = s.conn.write(message)
+}
@@ -0,0 +1,440 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const commonSource = `/// The state of an entry.
+enum Status {
+    Draft,
+    Published,
+}
+
+error NotFound {
+    id uuid
+}
+`
+
+const journalSource = `import "common.rpc"
+
+/// An entry in the journal.
+model Entry {
+    id     uuid
+    status Status
+}
+
+service Journal {
+    rpc GetEntry(id uuid) Entry throws NotFound
+}
+`
+
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// Talks to a server over pipes, the way an editor would.
+type client struct {
+	t        *testing.T
+	dir      string
+	in       *io.PipeWriter
+	conn     *conn
+	messages chan message
+	done     chan error
+	id       int
+}
+
+func startServer(t *testing.T, files map[string]string) *client {
+	dir := t.TempDir()
+	for name, source := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	c := &client{
+		t:        t,
+		dir:      dir,
+		in:       inWriter,
+		conn:     newConn(outReader, inWriter),
+		messages: make(chan message, 1000),
+		done:     make(chan error, 1),
+	}
+
+	go func() {
+		err := Serve(inReader, outWriter)
+		outWriter.Close()
+		c.done <- err
+	}()
+
+	go func() {
+		defer close(c.messages)
+		for {
+			body, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			msg := message{}
+			if json.Unmarshal(body, &msg) == nil {
+				c.messages <- msg
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		inWriter.Close()
+	})
+
+	c.request("initialize", map[string]any{"rootUri": pathToURI(dir)}, nil)
+	c.notify("initialized", map[string]any{})
+	return c
+}
+
+func (c *client) uri(name string) string {
+	return pathToURI(filepath.Join(c.dir, name))
+}
+
+func (c *client) notify(method string, params any) {
+	err := c.conn.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) next() message {
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the server closed the connection")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+	}
+	return message{}
+}
+
+// Sends a request and decodes the result into result, skipping over the
+// notifications sent before the response.
+func (c *client) request(method string, params any, result any) *responseError {
+	c.id++
+	id, _ := json.Marshal(c.id)
+	err := c.conn.write(map[string]any{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		msg := c.next()
+		if msg.Method != "" || string(msg.ID) != string(id) {
+			continue
+		}
+		if msg.Error == nil && result != nil {
+			err = json.Unmarshal(msg.Result, result)
+			if err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return msg.Error
+	}
+}
+
+// Waits for the diagnostics published for the document.
+func (c *client) diagnostics(uri string) []diagnostic {
+	for {
+		msg := c.next()
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+
+		params := publishDiagnosticsParams{}
+		err := json.Unmarshal(msg.Params, &params)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if params.URI == uri {
+			return params.Diagnostics
+		}
+	}
+}
+
+func (c *client) open(name string, text string) []diagnostic {
+	uri := c.uri(name)
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "rpc", "version": 1, "text": text},
+	})
+	return c.diagnostics(uri)
+}
+
+func (c *client) exit() error {
+	c.request("shutdown", nil, nil)
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server to exit")
+	}
+	return nil
+}
+
+func at(uri string, line int, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+func span(startLine, startCharacter, endLine, endCharacter int) textRange {
+	return textRange{
+		Start: position{Line: startLine, Character: startCharacter},
+		End:   position{Line: endLine, Character: endCharacter},
+	}
+}
+
+func TestServerPublishesSyntaxErrors(t *testing.T) {
+	c := startServer(t, nil)
+	diagnostics := c.open("broken.rpc", "model Entry {\n    id uuid\n    title\n}\n")
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, but got %v", diagnostics)
+	}
+	ExpectEqual(t, "range", span(3, 0, 3, 1), diagnostics[0].Range)
+	ExpectEqual(t, "message", "expected \"identifier\", but found \"}\"", diagnostics[0].Message)
+}
+
+func TestServerPublishesAnalysisErrors(t *testing.T) {
+	c := startServer(t, nil)
+	diagnostics := c.open("entry.rpc", "model Entry {\n    id     uuid\n    status Status\n}\n")
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, but got %v", diagnostics)
+	}
+	ExpectEqual(t, "range", span(2, 4, 2, 10), diagnostics[0].Range)
+	ExpectEqual(t, "message", "undefined type 'Status'", diagnostics[0].Message)
+
+	c.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": c.uri("entry.rpc"), "version": 2},
+		"contentChanges": []map[string]any{{"text": "enum Status { Draft }\n\nmodel Entry {\n    status Status\n}\n"}},
+	})
+	ExpectEqual(t, "diagnostics after change", 0, len(c.diagnostics(c.uri("entry.rpc"))))
+}
+
+func TestServerReportsMissingImports(t *testing.T) {
+	c := startServer(t, nil)
+	diagnostics := c.open("entry.rpc", "import \"missing.rpc\"\n\nmodel Entry {\n    id uuid\n}\n")
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, but got %v", diagnostics)
+	}
+	ExpectEqual(t, "range", span(0, 0, 0, 6), diagnostics[0].Range)
+	if !strings.HasPrefix(diagnostics[0].Message, "problem importing \"missing.rpc\"") {
+		t.Errorf("unexpected message %q", diagnostics[0].Message)
+	}
+}
+
+func TestServerPutsAnalysisErrorsOnMembers(t *testing.T) {
+	c := startServer(t, nil)
+	diagnostics := c.open("entry.rpc", "rpc GetEntry(\n    id int,\n    @deprecated limit int @minLength(1),\n)\n")
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, but got %v", diagnostics)
+	}
+	ExpectEqual(t, "range", span(2, 16, 2, 21), diagnostics[0].Range)
+	ExpectEqual(t, "message", "@minLength on parameter 'limit' of RPC 'GetEntry' can only be used on strings, not 'int'", diagnostics[0].Message)
+}
+
+func TestServerReportsImportCycles(t *testing.T) {
+	files := map[string]string{
+		"common.rpc": commonSource,
+		"a.rpc":      "import \"b.rpc\"\n",
+		"b.rpc":      "import \"a.rpc\"\n",
+	}
+	c := startServer(t, files)
+	diagnostics := c.open("entry.rpc", "import \"common.rpc\"\nimport \"a.rpc\"\n")
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, but got %v", diagnostics)
+	}
+	ExpectEqual(t, "range", span(1, 0, 1, 6), diagnostics[0].Range)
+	if !strings.HasPrefix(diagnostics[0].Message, "import cycle: ") {
+		t.Errorf("unexpected message %q", diagnostics[0].Message)
+	}
+}
+
+func TestServerAnalysesUnsavedImports(t *testing.T) {
+	c := startServer(t, map[string]string{"common.rpc": commonSource, "journal.rpc": journalSource})
+	ExpectEqual(t, "journal diagnostics", 0, len(c.open("journal.rpc", journalSource)))
+
+	c.open("common.rpc", strings.Replace(commonSource, "enum Status", "enum State", 1))
+	diagnostics := c.diagnostics(c.uri("journal.rpc"))
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, but got %v", diagnostics)
+	}
+	ExpectEqual(t, "message", "undefined type 'Status'", diagnostics[0].Message)
+}
+
+func TestServerGoesToDefinitionsInImports(t *testing.T) {
+	c := startServer(t, map[string]string{"common.rpc": commonSource, "journal.rpc": journalSource})
+	c.open("journal.rpc", journalSource)
+
+	locations := make([]location, 0)
+	c.request("textDocument/definition", at(c.uri("journal.rpc"), 5, 12), &locations)
+	ExpectEqual(t, "status definition", []location{{URI: c.uri("common.rpc"), Range: span(1, 5, 1, 11)}}, locations)
+
+	c.request("textDocument/definition", at(c.uri("journal.rpc"), 9, 40), &locations)
+	ExpectEqual(t, "thrown error definition", []location{{URI: c.uri("common.rpc"), Range: span(6, 6, 6, 14)}}, locations)
+
+	c.request("textDocument/definition", at(c.uri("journal.rpc"), 9, 28), &locations)
+	ExpectEqual(t, "return type definition", []location{{URI: c.uri("journal.rpc"), Range: span(3, 6, 3, 11)}}, locations)
+
+	var none []location
+	c.request("textDocument/definition", at(c.uri("journal.rpc"), 4, 12), &none)
+	ExpectEqual(t, "built-in definition", 0, len(none))
+}
+
+func TestServerFindsReferencesAcrossTheWorkspace(t *testing.T) {
+	c := startServer(t, map[string]string{"common.rpc": commonSource, "journal.rpc": journalSource})
+
+	params := at(c.uri("common.rpc"), 1, 7)
+	params["context"] = map[string]any{"includeDeclaration": true}
+	locations := make([]location, 0)
+	c.request("textDocument/references", params, &locations)
+	ExpectEqual(t, "references", []location{
+		{URI: c.uri("common.rpc"), Range: span(1, 5, 1, 11)},
+		{URI: c.uri("journal.rpc"), Range: span(5, 11, 5, 17)},
+	}, locations)
+
+	params["context"] = map[string]any{"includeDeclaration": false}
+	c.request("textDocument/references", params, &locations)
+	ExpectEqual(t, "references without the declaration", []location{
+		{URI: c.uri("journal.rpc"), Range: span(5, 11, 5, 17)},
+	}, locations)
+}
+
+func TestServerShowsResolvedTypesOnHover(t *testing.T) {
+	c := startServer(t, map[string]string{"common.rpc": commonSource, "journal.rpc": journalSource})
+	c.open("journal.rpc", journalSource)
+
+	result := hover{}
+	c.request("textDocument/hover", at(c.uri("journal.rpc"), 5, 6), &result)
+	ExpectEqual(t, "field hover", "```rpc\nstatus Status\n```\n\n```rpc\nenum Status {\n    Draft,\n    Published,\n}\n```\n\nThe state of an entry.", result.Contents.Value)
+	ExpectEqual(t, "field hover range", span(5, 4, 5, 10), result.Range)
+
+	c.request("textDocument/hover", at(c.uri("journal.rpc"), 9, 29), &result)
+	ExpectEqual(t, "type hover", "```rpc\nmodel Entry {\n    id     uuid\n    status Status\n}\n```\n\nAn entry in the journal.", result.Contents.Value)
+
+	c.request("textDocument/hover", at(c.uri("journal.rpc"), 4, 13), &result)
+	ExpectEqual(t, "built-in hover", "```rpc\nuuid\n```\n\n"+builtinDocs["uuid"], result.Contents.Value)
+
+	var none *hover
+	c.request("textDocument/hover", at(c.uri("journal.rpc"), 0, 0), &none)
+	ExpectEqual(t, "keyword hover", (*hover)(nil), none)
+}
+
+func TestServerCompletesTypes(t *testing.T) {
+	c := startServer(t, map[string]string{"common.rpc": commonSource})
+	source := "import \"common.rpc\"\n\nmodel Entry {\n    status St\n}\n\nrpc GetEntry() Entry throws \n"
+	c.open("journal.rpc", source)
+
+	labels := func(items []completionItem) []string {
+		names := make([]string, 0, len(items))
+		for _, item := range items {
+			names = append(names, item.Label)
+		}
+		return names
+	}
+
+	items := make([]completionItem, 0)
+	c.request("textDocument/completion", at(c.uri("journal.rpc"), 3, 13), &items)
+	ExpectEqual(t, "type completions", []string{"bool", "int", "float", "string", "uuid", "date", "map", "Entry", "Status"}, labels(items))
+
+	c.request("textDocument/completion", at(c.uri("journal.rpc"), 6, 28), &items)
+	ExpectEqual(t, "thrown error completions", []string{"NotFound"}, labels(items))
+	ExpectEqual(t, "error detail", "error", items[0].Detail)
+}
+
+func TestServerListsDocumentSymbols(t *testing.T) {
+	c := startServer(t, map[string]string{"common.rpc": commonSource, "journal.rpc": journalSource})
+
+	symbols := make([]documentSymbol, 0)
+	c.request("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": c.uri("journal.rpc")}}, &symbols)
+
+	names := make([]string, 0)
+	var visit func(prefix string, symbols []documentSymbol)
+	visit = func(prefix string, symbols []documentSymbol) {
+		for _, symbol := range symbols {
+			names = append(names, prefix+symbol.Name)
+			visit(prefix+symbol.Name+".", symbol.Children)
+		}
+	}
+	visit("", symbols)
+
+	ExpectEqual(t, "symbols", []string{"Entry", "Entry.id", "Entry.status", "Journal", "Journal.GetEntry"}, names)
+	ExpectEqual(t, "entry kind", symbolKindStruct, symbols[0].Kind)
+	ExpectEqual(t, "entry range", span(3, 0, 6, 1), symbols[0].Range)
+	ExpectEqual(t, "entry selection range", span(3, 6, 3, 11), symbols[0].SelectionRange)
+	ExpectEqual(t, "field detail", "Status", symbols[0].Children[1].Detail)
+	ExpectEqual(t, "rpc kind", symbolKindMethod, symbols[1].Children[0].Kind)
+}
+
+func TestServerCountsUTF16CodeUnits(t *testing.T) {
+	c := startServer(t, nil)
+	// the emoji is one rune, but two UTF-16 code units
+	diagnostics := c.open("entry.rpc", "model Entry {\n    /* 🙂 */ status Status\n}\n")
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, but got %v", diagnostics)
+	}
+
+	locations := make([]location, 0)
+	c.request("textDocument/definition", at(c.uri("entry.rpc"), 1, 21), &locations)
+	ExpectEqual(t, "definition", 0, len(locations))
+
+	result := hover{}
+	c.request("textDocument/hover", at(c.uri("entry.rpc"), 1, 13), &result)
+	ExpectEqual(t, "hover range", span(1, 13, 1, 19), result.Range)
+}
+
+func TestServerExits(t *testing.T) {
+	c := startServer(t, nil)
+	ExpectEqual(t, "exit error", nil, c.exit())
+
+	c = startServer(t, nil)
+	c.notify("exit", nil)
+	err := <-c.done
+	if !errors.Is(err, ErrNoShutdown) {
+		t.Errorf("expected ErrNoShutdown, but got %v", err)
+	}
+}
+
+func TestServerRejectsUnknownRequests(t *testing.T) {
+	c := startServer(t, nil)
+	err := c.request("textDocument/rename", at(c.uri("entry.rpc"), 0, 0), nil)
+	if err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected a method not found error, but got %v", err)
+	}
+}
+
+// Compares the values as JSON, which is how the client sees them.
+func ExpectEqual[T any](t *testing.T, name string, expected T, actual T) {
+	t.Helper()
+	expectedJSON, _ := json.Marshal(expected)
+	actualJSON, _ := json.Marshal(actual)
+	if string(expectedJSON) != string(actualJSON) {
+		t.Errorf("expected %s to be %s, but it was %s", name, expectedJSON, actualJSON)
+	}
+}
@@ -0,0 +1,75 @@
+package lsp
+
+import "github.com/fireland15/rpc-gen/internal/syntax"
+
+// Lists the document's declarations, with the fields, values, variants and
+// RPCs within them.
+func (s *server) documentSymbols(params documentSymbolParams) []documentSymbol {
+	symbols := make([]documentSymbol, 0)
+	doc := s.document(newSnapshot(s.documents), params.TextDocument.URI)
+	if doc == nil {
+		return symbols
+	}
+
+	for _, node := range doc.tree.Nodes() {
+		if symbol, ok := doc.symbol(node); ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+func (d *document) symbol(n *syntax.Node) (documentSymbol, bool) {
+	name, ok := n.Name()
+	if !ok {
+		return documentSymbol{}, false
+	}
+
+	symbol := documentSymbol{
+		Name:           name.Text,
+		Detail:         n.Kind.String(),
+		Range:          d.rangeOf(n.Span()),
+		SelectionRange: d.rangeOf(name.Span),
+	}
+	switch n.Kind {
+	case syntax.NodeModel:
+		symbol.Kind = symbolKindStruct
+	case syntax.NodeError:
+		symbol.Kind = symbolKindClass
+	case syntax.NodeEnum:
+		symbol.Kind = symbolKindEnum
+	case syntax.NodeUnion:
+		symbol.Kind = symbolKindInterface
+	case syntax.NodeService:
+		symbol.Kind = symbolKindModule
+	case syntax.NodeRpc:
+		symbol.Kind = symbolKindFunction
+	case syntax.NodeField, syntax.NodeVariant:
+		symbol.Kind = symbolKindField
+		if typ, ok := n.NodeOf(syntax.NodeType); ok {
+			symbol.Detail = typ.Text()
+		}
+	case syntax.NodeEnumValue:
+		symbol.Kind = symbolKindEnumMember
+		symbol.Detail = ""
+	default:
+		return documentSymbol{}, false
+	}
+
+	if n.Kind == syntax.NodeRpc {
+		// the parameters are part of the signature rather than members
+		return symbol, true
+	}
+
+	for _, child := range n.Nodes() {
+		member, ok := d.symbol(child)
+		if !ok {
+			continue
+		}
+		if member.Kind == symbolKindFunction {
+			member.Kind = symbolKindMethod
+		}
+		symbol.Children = append(symbol.Children, member)
+	}
+	return symbol, true
+}
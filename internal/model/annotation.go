@@ -0,0 +1,68 @@
+package model
+
+import (
+	"slices"
+	"strconv"
+)
+
+type ValueKind int
+
+const (
+	ValueKindString ValueKind = iota
+	ValueKindNumber
+	ValueKindIdentifier
+)
+
+// A literal written in a definition file.
+type Value struct {
+	Kind ValueKind
+	// The contents of a string, the digits of a number or the name of an
+	// identifier.
+	Text string
+}
+
+// Returns the value as it would be written in a definition file.
+func (v Value) String() string {
+	if v.Kind == ValueKindString {
+		return strconv.Quote(v.Text)
+	}
+	return v.Text
+}
+
+// Metadata attached to a declaration, written as @name or @name(arguments...).
+type Annotation struct {
+	Name      string
+	Arguments []Value
+}
+
+type Annotations []Annotation
+
+// Returns the first annotation with the given name.
+func (a Annotations) Find(name string) (Annotation, bool) {
+	for _, annotation := range a {
+		if annotation.Name == name {
+			return annotation, true
+		}
+	}
+	return Annotation{}, false
+}
+
+func (a Annotations) Has(name string) bool {
+	_, found := a.Find(name)
+	return found
+}
+
+// The names of the annotations which constrain the values of a field or
+// parameter.
+var ConstraintNames = []string{"minLength", "maxLength", "pattern", "range", "minItems", "maxItems"}
+
+// Returns the annotations which are validation constraints.
+func (a Annotations) Constraints() Annotations {
+	var constraints Annotations
+	for _, annotation := range a {
+		if slices.Contains(ConstraintNames, annotation.Name) {
+			constraints = append(constraints, annotation)
+		}
+	}
+	return constraints
+}
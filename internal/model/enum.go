@@ -0,0 +1,15 @@
+package model
+
+type Enum struct {
+	Name        string
+	Doc         string
+	Values      []EnumValue
+	Annotations Annotations
+	Source      Source
+}
+
+type EnumValue struct {
+	Name   string
+	Doc    string
+	Source Source
+}
@@ -0,0 +1,37 @@
+package model
+
+import "strconv"
+
+// An error which an RPC can respond with instead of its return value. Its
+// fields are sent to the client as the error's details.
+type Error struct {
+	Name        string
+	Doc         string
+	Fields      []Field
+	Annotations Annotations
+	Source      Source
+}
+
+// The HTTP status code of responses holding the error, which can be set with
+// the @status annotation. It defaults to 400 Bad Request.
+func (e Error) Status() int {
+	annotation, found := e.Annotations.Find("status")
+	if found && len(annotation.Arguments) == 1 {
+		if status, err := strconv.Atoi(annotation.Arguments[0].Text); err == nil {
+			return status
+		}
+	}
+	return 400
+}
+
+// Returns a model with the error's fields, which generators can use to
+// declare the type of its details.
+func (e Error) Model() Model {
+	return Model{
+		Name:        e.Name,
+		Doc:         e.Doc,
+		Fields:      e.Fields,
+		Annotations: e.Annotations,
+		Source:      e.Source,
+	}
+}
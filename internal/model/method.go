@@ -2,22 +2,137 @@ package model
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/iancoleman/strcase"
 )
 
 type Method struct {
-	Name          string
-	Parameters    []MethodParameter
-	ReturnType    *Type
-	ParameterType Type
+	Name string
+	// The name of the service block the method was declared in, or empty when
+	// it was declared at the top level.
+	Service    string
+	Doc        string
+	Parameters []MethodParameter
+	ReturnType *Type
+	// Whether the method sends a stream of values of its return type rather
+	// than a single value.
+	Stream bool
+	// The type of the messages streamed by the client of a bidirectional
+	// method, or nil for other methods. Bidirectional methods have no other
+	// parameters, and always stream their return type.
+	StreamParameter *Type
+	ParameterType   Type
+	// The names of the errors the method can respond with.
+	Throws      []string
+	Annotations Annotations
+	Source      Source
 }
 
 type MethodParameter struct {
 	Name string
 	Type Type
+	Doc  string
+	// The value used when the parameter is missing, or nil when it has none.
+	Default     *Value
+	Annotations Annotations
+	Source      Source
+}
+
+// Returns the name of the parameter in JSON, which can be overridden with the
+// @json annotation.
+func (p MethodParameter) JSONName() string {
+	return jsonName(p.Name, p.Annotations)
+}
+
+// Returns whether the method streams messages in both directions.
+func (m Method) Bidirectional() bool {
+	return m.StreamParameter != nil
+}
+
+// Returns the name of the method in JSON-RPC requests, which is qualified by
+// its service's name, e.g. "Journal.GetEntry".
+func (m Method) QualifiedName() string {
+	if m.Service != "" {
+		return fmt.Sprintf("%s.%s", m.Service, m.Name)
+	}
+	return m.Name
 }
 
 func (m Method) Path() string {
+	if m.Service != "" {
+		return fmt.Sprintf("/%s/%s", strcase.ToSnake(m.Service), strcase.ToSnake(m.Name))
+	}
 	return fmt.Sprintf("/%s", strcase.ToSnake(m.Name))
 }
+
+// The HTTP methods a route can use.
+var HTTPMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// The HTTP method and path a method is served at.
+type Route struct {
+	Method string
+	// May hold variables bound to parameters, each a whole segment like {id}.
+	Path string
+}
+
+// Returns the method's route, which can be set with @http(METHOD, "/path").
+// By default it's a POST to its path, or a GET for bidirectional methods.
+func (m Method) Route() Route {
+	if annotation, found := m.Annotations.Find("http"); found && len(annotation.Arguments) == 2 {
+		return Route{Method: annotation.Arguments[0].Text, Path: annotation.Arguments[1].Text}
+	}
+	if m.Bidirectional() {
+		return Route{Method: "GET", Path: m.Path()}
+	}
+	return Route{Method: "POST", Path: m.Path()}
+}
+
+// Returns whether the method's route was set with @http.
+func (m Method) HasRoute() bool {
+	return m.Annotations.Has("http")
+}
+
+// Returns the parameters sent in the query string, which are those not in the
+// path of a route without a body.
+func (m Method) QueryParameters() []MethodParameter {
+	route := m.Route()
+	if route.HasBody() {
+		return nil
+	}
+
+	params := make([]MethodParameter, 0, len(m.Parameters))
+	for _, p := range m.Parameters {
+		if !slices.Contains(route.Variables(), p.Name) {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// Returns whether the parameters which aren't in the path are sent in a JSON
+// body, rather than the query string.
+func (r Route) HasBody() bool {
+	return r.Method != "GET" && r.Method != "DELETE"
+}
+
+// Returns the names of the variables in the route's path, e.g. "id" in
+// "/entries/{id}".
+func (r Route) Variables() []string {
+	names := make([]string, 0)
+	for _, segment := range strings.Split(r.Path, "/") {
+		if name, found := PathVariable(segment); found {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Returns the name of the variable a path segment holds, if it's one.
+func PathVariable(segment string) (string, bool) {
+	if len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
@@ -1,11 +1,45 @@
 package model
 
+import "github.com/iancoleman/strcase"
+
 type Model struct {
-	Name   string
-	Fields []Field
+	Name        string
+	Doc         string
+	Fields      []Field
+	Annotations Annotations
+	Source      Source
 }
 
 type Field struct {
 	Name string
 	Type Type
+	Doc  string
+	// The value used when the field is missing, or nil when it has none.
+	Default     *Value
+	Annotations Annotations
+	Source      Source
+}
+
+// Returns the name of the field in JSON, which can be overridden with the
+// @json annotation.
+func (f Field) JSONName() string {
+	return jsonName(f.Name, f.Annotations)
+}
+
+func jsonName(name string, annotations Annotations) string {
+	annotation, found := annotations.Find("json")
+	if found && len(annotation.Arguments) == 1 && annotation.Arguments[0].Kind == ValueKindString {
+		return annotation.Arguments[0].Text
+	}
+	return strcase.ToLowerCamel(name)
+}
+
+// Returns true when any of the model's fields has a default value.
+func (m Model) HasDefaults() bool {
+	for _, f := range m.Fields {
+		if f.Default != nil {
+			return true
+		}
+	}
+	return false
 }
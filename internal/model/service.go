@@ -0,0 +1,9 @@
+package model
+
+// A named group of RPCs declared with a service block.
+type Service struct {
+	Name        string
+	Doc         string
+	Annotations Annotations
+	Source      Source
+}
@@ -4,4 +4,29 @@ type ServiceDefinition struct {
 	Name    string
 	Methods []Method
 	Models  []Model
+	Enums   []Enum
+	Unions  []Union
+	Errors  []Error
+	// The named services. Methods refer to the service they belong to by name.
+	Services []Service
+	Imports  []Import
+	// The definition files the service was loaded from, imported files first.
+	Files []string
+}
+
+// Returns the methods declared in the named service. The methods declared
+// outside of any service block belong to the service with an empty name.
+func (s ServiceDefinition) MethodsOf(service string) []Method {
+	methods := make([]Method, 0)
+	for _, m := range s.Methods {
+		if m.Service == service {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+type Import struct {
+	Path   string
+	Source Source
 }
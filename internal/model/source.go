@@ -0,0 +1,15 @@
+package model
+
+import "fmt"
+
+// Where a declaration was written. Line and Column are zero based, like the
+// positions reported by the lexer.
+type Source struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (s Source) String() string {
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line+1, s.Column+1)
+}
@@ -8,12 +8,16 @@ const (
 	TypeVariantNamed TypeVariant = iota
 	TypeVariantArray
 	TypeVariantOptional
+	TypeVariantMap
 )
 
 type Type struct {
 	Name    string
 	Variant TypeVariant
-	Inner   *Type
+	// The element type of arrays and optionals, and the value type of maps.
+	Inner *Type
+	// The key type of maps.
+	Key *Type
 }
 
 func (t Type) String() string {
@@ -23,6 +27,8 @@ func (t Type) String() string {
 		return fmt.Sprintf("%s[]", t.Inner.String())
 	} else if t.Variant == TypeVariantOptional {
 		return fmt.Sprintf("%s?", t.Inner.String())
+	} else if t.Variant == TypeVariantMap {
+		return fmt.Sprintf("map<%s, %s>", t.Key.String(), t.Inner.String())
 	}
 	panic("unreachable")
 }
@@ -0,0 +1,28 @@
+package model
+
+import "github.com/iancoleman/strcase"
+
+// A union holds exactly one of its variants. The variant is identified by the
+// value of the discriminator field in its JSON representation.
+type Union struct {
+	Name          string
+	Doc           string
+	Discriminator string
+	Variants      []UnionVariant
+	Annotations   Annotations
+	Source        Source
+}
+
+// Returns the name of the discriminator field in the union's JSON
+// representation.
+func (u Union) DiscriminatorJSONName() string {
+	return strcase.ToLowerCamel(u.Discriminator)
+}
+
+type UnionVariant struct {
+	// The value of the discriminator field identifying the variant.
+	Name   string
+	Type   Type
+	Doc    string
+	Source Source
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/fireland15/rpc-gen/internal/lexing"
@@ -32,8 +33,16 @@ type Keyword string
 
 const (
 	KwModel    Keyword = "model"
+	KwEnum     Keyword = "enum"
+	KwUnion    Keyword = "union"
 	KwOptional Keyword = "optional"
 	KwRpc      Keyword = "rpc"
+	KwMap      Keyword = "map"
+	KwImport   Keyword = "import"
+	KwService  Keyword = "service"
+	KwError    Keyword = "error"
+	KwThrows   Keyword = "throws"
+	KwStream   Keyword = "stream"
 )
 
 func (p *Parser) Parse() (model.ServiceDefinition, error) {
@@ -41,12 +50,21 @@ func (p *Parser) Parse() (model.ServiceDefinition, error) {
 	parseErrors := make([]string, 0)
 
 	for {
-		tok, err := p.tokens.Lookahead(0)
+		tok, err := p.lookaheadPastAnnotations()
 		if err != nil {
 			break
 		}
 
-		if tok.Text == string(KwModel) {
+		if tok.Text == string(KwImport) {
+			imp, err := p.parseImport()
+			if err != nil {
+				msg := fmt.Sprintf("(%d:%d): %s", tok.Span.Start.Line, tok.Span.Start.Column, err)
+				parseErrors = append(parseErrors, msg)
+				continue
+			}
+			def.Imports = append(def.Imports, imp)
+			continue
+		} else if tok.Text == string(KwModel) {
 			md, err := p.parseModelDefinition()
 			if err != nil {
 				continue
@@ -60,13 +78,47 @@ func (p *Parser) Parse() (model.ServiceDefinition, error) {
 			}
 			def.Methods = append(def.Methods, rd)
 			continue
+		} else if tok.Text == string(KwEnum) {
+			ed, err := p.parseEnumDefinition()
+			if err != nil {
+				continue
+			}
+			def.Enums = append(def.Enums, ed)
+			continue
+		} else if tok.Text == string(KwService) {
+			sd, methods, err := p.parseServiceDefinition()
+			if err != nil {
+				continue
+			}
+			def.Services = append(def.Services, sd)
+			def.Methods = append(def.Methods, methods...)
+			continue
+		} else if tok.Text == string(KwUnion) {
+			ud, err := p.parseUnionDefinition()
+			if err != nil {
+				continue
+			}
+			def.Unions = append(def.Unions, ud)
+			continue
+		} else if tok.Text == string(KwError) {
+			ed, err := p.parseErrorDefinition()
+			if err != nil {
+				continue
+			}
+			def.Errors = append(def.Errors, ed)
+			continue
 		} else {
-			msg := fmt.Sprintf("(%d:%d): expected keyword \"model\" or \"rpc\", but got \"%s\" instead", tok.Span.Start.Line, tok.Span.Start.Column, tok.Type)
+			msg := fmt.Sprintf("(%d:%d): expected keyword \"import\", \"model\", \"enum\", \"union\", \"error\", \"service\" or \"rpc\", but got \"%s\" instead", tok.Span.Start.Line, tok.Span.Start.Column, tok.Type)
 			parseErrors = append(parseErrors, msg)
 			p.tokens.Next()
 		}
 	}
 
+	// the tokens have all been read, along with any problems in them
+	for _, e := range p.tokens.Errors() {
+		parseErrors = append(parseErrors, e.Error())
+	}
+
 	if len(parseErrors) > 0 {
 		err := errors.New(strings.Join(parseErrors, "\n"))
 		return def, err
@@ -74,9 +126,90 @@ func (p *Parser) Parse() (model.ServiceDefinition, error) {
 	return def, nil
 }
 
+func (p *Parser) parseImport() (model.Import, error) {
+	imp := model.Import{}
+	if tok, err := p.tokens.Lookahead(0); err == nil {
+		imp.Source = sourceOf(tok)
+	}
+
+	err := p.parseKeyword(KwImport)
+	if err != nil {
+		return imp, err
+	}
+
+	path, err := p.parseString()
+	if err != nil {
+		return imp, err
+	}
+
+	imp.Path = path
+	return imp, nil
+}
+
+func (p *Parser) parseServiceDefinition() (model.Service, []model.Method, error) {
+	definition := model.Service{}
+	methods := make([]model.Method, 0)
+	leading, err := p.parseLeading()
+	if err != nil {
+		return definition, methods, err
+	}
+
+	definition.Doc = leading.doc
+	definition.Annotations = leading.annotations
+	definition.Source = leading.source
+
+	err = p.parseKeyword(KwService)
+	if err != nil {
+		return definition, methods, err
+	}
+
+	serviceName, err := p.parseIdentifier()
+	if err != nil {
+		return definition, methods, err
+	}
+
+	definition.Name = serviceName
+
+	err = p.parseLeftBracket()
+	if err != nil {
+		return definition, methods, err
+	}
+
+	for {
+		tok, err := p.lookaheadPastAnnotations()
+		if err != nil || tok.Text != string(KwRpc) {
+			break
+		}
+
+		method, err := p.parseRpcDefinition()
+		if err != nil {
+			return definition, methods, err
+		}
+
+		method.Service = serviceName
+		methods = append(methods, method)
+	}
+
+	err = p.parseRightBracket()
+	if err != nil {
+		return definition, methods, err
+	}
+
+	return definition, methods, nil
+}
+
 func (p *Parser) parseRpcDefinition() (model.Method, error) {
 	method := model.Method{}
-	err := p.parseKeyword(KwRpc)
+	leading, err := p.parseLeading()
+	if err != nil {
+		return method, err
+	}
+
+	method.Doc = leading.doc
+	method.Annotations = leading.annotations
+	method.Source = leading.source
+
+	err = p.parseKeyword(KwRpc)
 	if err != nil {
 		return method, err
 	}
@@ -93,24 +226,58 @@ func (p *Parser) parseRpcDefinition() (model.Method, error) {
 		return method, err
 	}
 
-	for {
+	// a bidirectional RPC takes the stream of messages from the client in place
+	// of its parameters
+	if tok, err := p.tokens.Lookahead(0); err == nil && tok.Type == lexing.TokenTypeIdentifier && tok.Text == string(KwStream) {
+		p.tokens.Next()
+		ty, err := p.parseType()
+		if err != nil {
+			return method, err
+		}
+		method.StreamParameter = &ty
+	}
+
+	for method.StreamParameter == nil {
 		parameter := model.MethodParameter{}
 		tok, err := p.tokens.Lookahead(0)
-		if err != nil || tok.Type != lexing.TokenTypeIdentifier {
+		if err != nil || (tok.Type != lexing.TokenTypeIdentifier && tok.Type != lexing.TokenTypeAt) {
 			break
 		}
 
-		tok, err = p.tokens.Next()
+		leading, err := p.parseLeading()
 		if err != nil {
-			panic("lookahead failed?")
+			return method, err
+		}
+		parameter.Doc = leading.doc
+		parameter.Annotations = leading.annotations
+		parameter.Source = leading.source
+
+		tok, err = p.tokens.Lookahead(0)
+		if err != nil {
+			return method, err
+		}
+
+		parameter.Name, err = p.parseIdentifier()
+		if err != nil {
+			return method, err
 		}
-		parameter.Name = tok.Text
 
 		ty, err := p.parseType()
 		if err != nil {
 			return method, err
 		}
 		parameter.Type = ty
+
+		parameter.Default, err = p.parseDefault()
+		if err != nil {
+			return method, err
+		}
+
+		trailing, err := p.parseAnnotations(tok.Span.Start.Line)
+		if err != nil {
+			return method, err
+		}
+		parameter.Annotations = append(parameter.Annotations, trailing...)
 		method.Parameters = append(method.Parameters, parameter)
 
 		tok, err = p.tokens.Lookahead(0)
@@ -126,7 +293,18 @@ func (p *Parser) parseRpcDefinition() (model.Method, error) {
 	}
 
 	next, err := p.tokens.Lookahead(0)
-	if err == nil {
+	if err == nil && next.Type == lexing.TokenTypeIdentifier && next.Text == string(KwStream) {
+		p.tokens.Next()
+		ty, err := p.parseType()
+		if err != nil {
+			return method, err
+		}
+		method.ReturnType = &ty
+		method.Stream = true
+	} else if method.StreamParameter != nil {
+		err = fmt.Errorf("expected a stream return type for bidirectional RPC '%s': %w", method.Name, ErrUnexpectedToken)
+		return method, err
+	} else if err == nil {
 		if next.Type == lexing.TokenTypeIdentifier && !isKeyword(next.Text) {
 			ty, err := p.parseType()
 			if err != nil {
@@ -136,6 +314,24 @@ func (p *Parser) parseRpcDefinition() (model.Method, error) {
 		}
 	}
 
+	next, err = p.tokens.Lookahead(0)
+	if err == nil && next.Type == lexing.TokenTypeIdentifier && next.Text == string(KwThrows) {
+		p.tokens.Next()
+		for {
+			name, err := p.parseIdentifier()
+			if err != nil {
+				return method, err
+			}
+			method.Throws = append(method.Throws, name)
+
+			tok, err := p.tokens.Lookahead(0)
+			if err != nil || tok.Type != lexing.TokenTypeComma {
+				break
+			}
+			p.tokens.Next()
+		}
+	}
+
 	return method, nil
 }
 
@@ -150,9 +346,53 @@ func (p *Parser) parseType() (model.Type, error) {
 	ty.Name = name
 	ty.Variant = model.TypeVariantNamed
 
+	if tok, err := p.tokens.Lookahead(0); err == nil && name == string(KwMap) && tok.Type == lexing.TokenTypeLeftAngleBracket {
+		ty, err = p.parseMapType()
+		if err != nil {
+			return ty, err
+		}
+	}
+
 	return p.parseOuterType(ty)
 }
 
+// parses the "<key, value>" part of a map type. the map keyword has already
+// been consumed.
+func (p *Parser) parseMapType() (model.Type, error) {
+	ty := model.Type{
+		Variant: model.TypeVariantMap,
+	}
+
+	err := p.parseTokenType(lexing.TokenTypeLeftAngleBracket)
+	if err != nil {
+		return ty, err
+	}
+
+	key, err := p.parseType()
+	if err != nil {
+		return ty, err
+	}
+
+	err = p.parseTokenType(lexing.TokenTypeComma)
+	if err != nil {
+		return ty, err
+	}
+
+	value, err := p.parseType()
+	if err != nil {
+		return ty, err
+	}
+
+	err = p.parseTokenType(lexing.TokenTypeRightAngleBracket)
+	if err != nil {
+		return ty, err
+	}
+
+	ty.Key = &key
+	ty.Inner = &value
+	return ty, nil
+}
+
 func (p *Parser) parseOuterType(inner model.Type) (model.Type, error) {
 	tok, err := p.tokens.Lookahead(0)
 	if err != nil {
@@ -186,7 +426,16 @@ func (p *Parser) parseOuterType(inner model.Type) (model.Type, error) {
 
 func (p *Parser) parseModelDefinition() (model.Model, error) {
 	definition := model.Model{}
-	err := p.parseKeyword(KwModel)
+	leading, err := p.parseLeading()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Doc = leading.doc
+	definition.Annotations = leading.annotations
+	definition.Source = leading.source
+
+	err = p.parseKeyword(KwModel)
 	if err != nil {
 		return definition, err
 	}
@@ -220,13 +469,187 @@ func (p *Parser) parseModelDefinition() (model.Model, error) {
 	return definition, nil
 }
 
+func (p *Parser) parseErrorDefinition() (model.Error, error) {
+	definition := model.Error{}
+	leading, err := p.parseLeading()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Doc = leading.doc
+	definition.Annotations = leading.annotations
+	definition.Source = leading.source
+
+	err = p.parseKeyword(KwError)
+	if err != nil {
+		return definition, err
+	}
+
+	errorName, err := p.parseIdentifier()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Name = errorName
+
+	err = p.parseLeftBracket()
+	if err != nil {
+		return definition, err
+	}
+
+	for p.canParseModelFieldDefinition() {
+		fd, err := p.parseModelFieldDefinition()
+		if err != nil {
+			break
+		}
+
+		definition.Fields = append(definition.Fields, fd)
+	}
+
+	err = p.parseRightBracket()
+	if err != nil {
+		return definition, err
+	}
+
+	return definition, nil
+}
+
+func (p *Parser) parseEnumDefinition() (model.Enum, error) {
+	definition := model.Enum{}
+	leading, err := p.parseLeading()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Doc = leading.doc
+	definition.Annotations = leading.annotations
+	definition.Source = leading.source
+
+	err = p.parseKeyword(KwEnum)
+	if err != nil {
+		return definition, err
+	}
+
+	enumName, err := p.parseIdentifier()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Name = enumName
+
+	err = p.parseLeftBracket()
+	if err != nil {
+		return definition, err
+	}
+
+	for {
+		tok, err := p.tokens.Lookahead(0)
+		if err != nil || tok.Type != lexing.TokenTypeIdentifier {
+			break
+		}
+
+		p.tokens.Next()
+		definition.Values = append(definition.Values, model.EnumValue{
+			Name:   tok.Text,
+			Doc:    tok.Doc,
+			Source: sourceOf(tok),
+		})
+
+		tok, err = p.tokens.Lookahead(0)
+		if err != nil || tok.Type != lexing.TokenTypeComma {
+			break
+		}
+		p.tokens.Next()
+	}
+
+	err = p.parseRightBracket()
+	if err != nil {
+		return definition, err
+	}
+
+	return definition, nil
+}
+
+func (p *Parser) parseUnionDefinition() (model.Union, error) {
+	definition := model.Union{}
+	leading, err := p.parseLeading()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Doc = leading.doc
+	definition.Annotations = leading.annotations
+	definition.Source = leading.source
+
+	err = p.parseKeyword(KwUnion)
+	if err != nil {
+		return definition, err
+	}
+
+	unionName, err := p.parseIdentifier()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Name = unionName
+
+	err = p.parseTokenType(lexing.TokenTypeLeftParenthesis)
+	if err != nil {
+		return definition, err
+	}
+
+	discriminator, err := p.parseIdentifier()
+	if err != nil {
+		return definition, err
+	}
+
+	definition.Discriminator = discriminator
+
+	err = p.parseTokenType(lexing.TokenTypeRightParenthesis)
+	if err != nil {
+		return definition, err
+	}
+
+	err = p.parseLeftBracket()
+	if err != nil {
+		return definition, err
+	}
+
+	for {
+		variant := model.UnionVariant{}
+		tok, err := p.tokens.Lookahead(0)
+		if err != nil || tok.Type != lexing.TokenTypeIdentifier {
+			break
+		}
+		p.tokens.Next()
+
+		variant.Name = tok.Text
+		variant.Doc = tok.Doc
+		variant.Source = sourceOf(tok)
+
+		variant.Type, err = p.parseType()
+		if err != nil {
+			break
+		}
+
+		definition.Variants = append(definition.Variants, variant)
+	}
+
+	err = p.parseRightBracket()
+	if err != nil {
+		return definition, err
+	}
+
+	return definition, nil
+}
+
 func (p *Parser) canParseModelFieldDefinition() bool {
 	t, err := p.tokens.Lookahead(0)
 	if err != nil {
 		return false
 	}
 
-	if t.Type != lexing.TokenTypeIdentifier {
+	if t.Type != lexing.TokenTypeIdentifier && t.Type != lexing.TokenTypeAt {
 		return false
 	}
 
@@ -235,6 +658,20 @@ func (p *Parser) canParseModelFieldDefinition() bool {
 
 func (p *Parser) parseModelFieldDefinition() (model.Field, error) {
 	field := model.Field{}
+	leading, err := p.parseLeading()
+	if err != nil {
+		return field, err
+	}
+
+	field.Doc = leading.doc
+	field.Annotations = leading.annotations
+	field.Source = leading.source
+
+	tok, err := p.tokens.Lookahead(0)
+	if err != nil {
+		return field, err
+	}
+
 	fieldName, err := p.parseIdentifier()
 	if err != nil {
 		return field, err
@@ -249,9 +686,168 @@ func (p *Parser) parseModelFieldDefinition() (model.Field, error) {
 
 	field.Type = fieldType
 
+	field.Default, err = p.parseDefault()
+	if err != nil {
+		return field, err
+	}
+
+	trailing, err := p.parseAnnotations(tok.Span.Start.Line)
+	if err != nil {
+		return field, err
+	}
+
+	field.Annotations = append(field.Annotations, trailing...)
+
 	return field, nil
 }
 
+// the doc comment, position and annotations written before a declaration
+type leading struct {
+	doc         string
+	source      model.Source
+	annotations model.Annotations
+}
+
+func (p *Parser) parseLeading() (leading, error) {
+	l := leading{}
+	if tok, err := p.tokens.Lookahead(0); err == nil {
+		l.doc = tok.Doc
+		l.source = sourceOf(tok)
+	}
+
+	annotations, err := p.parseAnnotations(-1)
+	if err != nil {
+		return l, err
+	}
+
+	l.annotations = annotations
+	return l, nil
+}
+
+// parses the annotations at the current position. when line isn't negative
+// only the annotations starting on that line are parsed, which lets trailing
+// annotations be told apart from those of the next declaration.
+func (p *Parser) parseAnnotations(line int) (model.Annotations, error) {
+	var annotations model.Annotations
+	for {
+		tok, err := p.tokens.Lookahead(0)
+		if err != nil || tok.Type != lexing.TokenTypeAt {
+			return annotations, nil
+		}
+		if line >= 0 && tok.Span.Start.Line != line {
+			return annotations, nil
+		}
+		p.tokens.Next()
+
+		annotation := model.Annotation{}
+		annotation.Name, err = p.parseIdentifier()
+		if err != nil {
+			return annotations, err
+		}
+
+		tok, err = p.tokens.Lookahead(0)
+		if err == nil && tok.Type == lexing.TokenTypeLeftParenthesis {
+			p.tokens.Next()
+			for {
+				tok, err = p.tokens.Lookahead(0)
+				if err != nil || tok.Type == lexing.TokenTypeRightParenthesis {
+					break
+				}
+
+				value, err := p.parseValue()
+				if err != nil {
+					return annotations, err
+				}
+				annotation.Arguments = append(annotation.Arguments, value)
+
+				tok, err = p.tokens.Lookahead(0)
+				if err != nil || tok.Type != lexing.TokenTypeComma {
+					break
+				}
+				p.tokens.Next()
+			}
+
+			err = p.parseTokenType(lexing.TokenTypeRightParenthesis)
+			if err != nil {
+				return annotations, err
+			}
+		}
+
+		annotations = append(annotations, annotation)
+	}
+}
+
+// returns the first token after any annotations at the current position
+func (p *Parser) lookaheadPastAnnotations() (lexing.Token, error) {
+	n := 0
+	for {
+		tok, err := p.tokens.Lookahead(n)
+		if err != nil || tok.Type != lexing.TokenTypeAt {
+			return tok, err
+		}
+
+		// skip the @ and the annotation's name
+		n += 2
+		tok, err = p.tokens.Lookahead(n)
+		if err != nil || tok.Type != lexing.TokenTypeLeftParenthesis {
+			continue
+		}
+
+		for tok.Type != lexing.TokenTypeRightParenthesis {
+			n += 1
+			tok, err = p.tokens.Lookahead(n)
+			if err != nil {
+				return tok, err
+			}
+		}
+		n += 1
+	}
+}
+
+// parses the "= value" written after a field or parameter type, if there is one
+func (p *Parser) parseDefault() (*model.Value, error) {
+	tok, err := p.tokens.Lookahead(0)
+	if err != nil || tok.Type != lexing.TokenTypeEquals {
+		return nil, nil
+	}
+	p.tokens.Next()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func (p *Parser) parseValue() (model.Value, error) {
+	t, err := p.tokens.Next()
+	if err != nil {
+		return model.Value{}, err
+	}
+
+	switch t.Type {
+	case lexing.TokenTypeString:
+		text, err := strconv.Unquote(t.Text)
+		if err != nil {
+			err = fmt.Errorf("invalid string %s: %w", t.Text, err)
+			return model.Value{}, err
+		}
+		return model.Value{Kind: model.ValueKindString, Text: text}, nil
+	case lexing.TokenTypeNumber:
+		_, err := strconv.ParseFloat(t.Text, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid number %s: %w", t.Text, ErrUnexpectedToken)
+			return model.Value{}, err
+		}
+		return model.Value{Kind: model.ValueKindNumber, Text: t.Text}, nil
+	case lexing.TokenTypeIdentifier:
+		return model.Value{Kind: model.ValueKindIdentifier, Text: t.Text}, nil
+	default:
+		err = fmt.Errorf("expected a string, number or identifier, but found \"%s\": %w", t.Text, ErrUnexpectedToken)
+		return model.Value{}, err
+	}
+}
+
 func (p *Parser) parseLeftBracket() error {
 	return p.parseTokenType(lexing.TokenTypeLeftBracket)
 }
@@ -287,6 +883,25 @@ func (p *Parser) parseIdentifier() (string, error) {
 	return t.Text, nil
 }
 
+func (p *Parser) parseString() (string, error) {
+	t, err := p.tokens.Next()
+	if err != nil {
+		return "", err
+	}
+	if t.Type != lexing.TokenTypeString {
+		err = fmt.Errorf("expected string, but found \"%s\": %w", t.Text, ErrUnexpectedToken)
+		return "", err
+	}
+
+	value, err := strconv.Unquote(t.Text)
+	if err != nil {
+		err = fmt.Errorf("invalid string %s: %w", t.Text, err)
+		return "", err
+	}
+
+	return value, nil
+}
+
 func (p *Parser) parseKeyword(kw Keyword) error {
 	t, err := p.tokens.Next()
 	if err != nil {
@@ -304,5 +919,13 @@ func (p *Parser) parseKeyword(kw Keyword) error {
 }
 
 func isKeyword(str string) bool {
-	return str == string(KwModel) || str == string(KwRpc) || str == string(KwOptional)
+	return str == string(KwImport) || str == string(KwService) || str == string(KwModel) || str == string(KwEnum) || str == string(KwUnion) || str == string(KwRpc) || str == string(KwOptional) || str == string(KwError) || str == string(KwThrows) || str == string(KwStream)
+}
+
+// returns where tok starts. the file is filled in by whoever knows it.
+func sourceOf(tok lexing.Token) model.Source {
+	return model.Source{
+		Line:   tok.Span.Start.Line,
+		Column: tok.Span.Start.Column,
+	}
 }
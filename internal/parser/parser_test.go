@@ -3,6 +3,8 @@ package parser
 import (
 	"strings"
 	"testing"
+
+	"github.com/fireland15/rpc-gen/internal/model"
 )
 
 func TestParserParsesModelDefinition(t *testing.T) {
@@ -99,3 +101,477 @@ rpc SignOut() void`
 	ExpectEqual(t, "model count", 2, len(def.Models))
 	ExpectEqual(t, "rpc count", 2, len(def.Methods))
 }
+
+func TestParserIgnoresComments(t *testing.T) {
+	source := `
+// Models used for signing in.
+model SignInRequest {
+	username string // the user's email address
+	/* never logged */ password string
+}
+
+/*
+rpc SignOut() void
+*/
+rpc SignIn(request SignInRequest) void // returns nothing`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "model count", 1, len(def.Models))
+	ExpectEqual(t, "field count", 2, len(def.Models[0].Fields))
+	ExpectEqual(t, "rpc count", 1, len(def.Methods))
+	ExpectEqual(t, "rpc name", "SignIn", def.Methods[0].Name)
+}
+
+func TestParserAttachesDocComments(t *testing.T) {
+	source := `
+/// A user's credentials.
+model SignInRequest {
+	/// The user's email address.
+	username string
+	password string
+}
+
+/// Signs the user in.
+/// Fails when the credentials are wrong.
+rpc SignIn(
+	/// What to sign in with.
+	request SignInRequest
+) void`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "model doc", "A user's credentials.", def.Models[0].Doc)
+	ExpectEqual(t, "field doc", "The user's email address.", def.Models[0].Fields[0].Doc)
+	ExpectEqual(t, "field doc", "", def.Models[0].Fields[1].Doc)
+	ExpectEqual(t, "rpc doc", "Signs the user in.\nFails when the credentials are wrong.", def.Methods[0].Doc)
+	ExpectEqual(t, "parameter doc", "What to sign in with.", def.Methods[0].Parameters[0].Doc)
+}
+
+func TestParserParsesEnumDefinition(t *testing.T) {
+	source := `
+/// Where an entry is in its lifecycle.
+enum Status {
+	Draft,
+	/// Visible to everyone.
+	Published,
+	Archived,
+}
+
+model Entry {
+	status Status
+}`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "enum count", 1, len(def.Enums))
+	ExpectEqual(t, "model count", 1, len(def.Models))
+
+	e := def.Enums[0]
+	ExpectEqual(t, "enum name", "Status", e.Name)
+	ExpectEqual(t, "enum doc", "Where an entry is in its lifecycle.", e.Doc)
+	ExpectEqual(t, "enum value count", 3, len(e.Values))
+	ExpectEqual(t, "enum value", "Draft", e.Values[0].Name)
+	ExpectEqual(t, "enum value", "Published", e.Values[1].Name)
+	ExpectEqual(t, "enum value doc", "Visible to everyone.", e.Values[1].Doc)
+	ExpectEqual(t, "enum value", "Archived", e.Values[2].Name)
+}
+
+func TestParserParsesUnionDefinition(t *testing.T) {
+	source := `
+/// The outcome of signing in.
+union SignInResponse(kind) {
+	success SignInSuccess
+	/// The credentials were rejected.
+	failure SignInFailure
+}`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "union count", 1, len(def.Unions))
+
+	u := def.Unions[0]
+	ExpectEqual(t, "union name", "SignInResponse", u.Name)
+	ExpectEqual(t, "union doc", "The outcome of signing in.", u.Doc)
+	ExpectEqual(t, "union discriminator", "kind", u.Discriminator)
+	ExpectEqual(t, "union variant count", 2, len(u.Variants))
+	ExpectEqual(t, "variant name", "success", u.Variants[0].Name)
+	ExpectEqual(t, "variant type", "SignInSuccess", u.Variants[0].Type.String())
+	ExpectEqual(t, "variant name", "failure", u.Variants[1].Name)
+	ExpectEqual(t, "variant type", "SignInFailure", u.Variants[1].Type.String())
+	ExpectEqual(t, "variant doc", "The credentials were rejected.", u.Variants[1].Doc)
+}
+
+func TestParserParsesMapTypes(t *testing.T) {
+	source := `
+model Counters {
+	counts map<string, int>
+	nested map<uuid, map<string, float[]>>?
+}`
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	md, err := p.parseModelDefinition()
+	if err != nil {
+		t.Error(err)
+	}
+
+	ExpectEqual(t, "model definition field count", 2, len(md.Fields))
+	ExpectEqual(t, "field type", "map<string, int>", md.Fields[0].Type.String())
+	ExpectEqual(t, "field type", "map<uuid, map<string, float[]>>?", md.Fields[1].Type.String())
+	ExpectEqual(t, "key type", "string", md.Fields[0].Type.Key.Name)
+	ExpectEqual(t, "value type", "int", md.Fields[0].Type.Inner.Name)
+}
+
+func TestParserParsesImports(t *testing.T) {
+	source := `
+import "common.rpc"
+import "../shared/types.rpc"
+
+model Entry {
+	status Status
+}`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "import count", 2, len(def.Imports))
+	ExpectEqual(t, "import path", "common.rpc", def.Imports[0].Path)
+	ExpectEqual(t, "import line", 1, def.Imports[0].Source.Line)
+	ExpectEqual(t, "import path", "../shared/types.rpc", def.Imports[1].Path)
+	ExpectEqual(t, "model line", 4, def.Models[0].Source.Line)
+}
+
+func TestParserParsesServiceDefinition(t *testing.T) {
+	source := `
+rpc Ping()
+
+/// Reading and writing journal entries.
+service Journal {
+	/// Fetches a single entry.
+	rpc GetEntry(id uuid) JournalEntry
+
+	rpc DeleteEntry(id uuid)
+}`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "service count", 1, len(def.Services))
+	ExpectEqual(t, "service name", "Journal", def.Services[0].Name)
+	ExpectEqual(t, "service doc", "Reading and writing journal entries.", def.Services[0].Doc)
+	ExpectEqual(t, "rpc count", 3, len(def.Methods))
+	ExpectEqual(t, "rpc service", "", def.Methods[0].Service)
+	ExpectEqual(t, "rpc path", "/ping", def.Methods[0].Path())
+	ExpectEqual(t, "rpc service", "Journal", def.Methods[1].Service)
+	ExpectEqual(t, "rpc doc", "Fetches a single entry.", def.Methods[1].Doc)
+	ExpectEqual(t, "rpc path", "/journal/get_entry", def.Methods[1].Path())
+	ExpectEqual(t, "rpc path", "/journal/delete_entry", def.Methods[2].Path())
+	ExpectEqual(t, "service methods", 2, len(def.MethodsOf("Journal")))
+}
+
+func TestParserParsesAnnotations(t *testing.T) {
+	source := `
+/// A journal entry.
+@table("entries") @version(2)
+model Entry {
+	@deprecated id uuid
+	createdOn date @json("created_on")
+	title string
+}
+
+@http(GET, "/entries/{id}")
+rpc GetEntry(@json("entry_id") id uuid, verbose bool @flag) Entry`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	m := def.Models[0]
+	ExpectEqual(t, "model doc", "A journal entry.", m.Doc)
+	ExpectEqual(t, "model annotation count", 2, len(m.Annotations))
+	ExpectEqual(t, "annotation name", "table", m.Annotations[0].Name)
+	ExpectEqual(t, "annotation argument", "entries", m.Annotations[0].Arguments[0].Text)
+	ExpectEqual(t, "annotation argument kind", model.ValueKindString, m.Annotations[0].Arguments[0].Kind)
+	ExpectEqual(t, "annotation argument kind", model.ValueKindNumber, m.Annotations[1].Arguments[0].Kind)
+
+	ExpectEqual(t, "field count", 3, len(m.Fields))
+	ExpectEqual(t, "field deprecated", true, m.Fields[0].Annotations.Has("deprecated"))
+	ExpectEqual(t, "field json name", "created_on", m.Fields[1].JSONName())
+	ExpectEqual(t, "field annotation count", 0, len(m.Fields[2].Annotations))
+
+	method := def.Methods[0]
+	http, found := method.Annotations.Find("http")
+	ExpectEqual(t, "rpc annotation found", true, found)
+	ExpectEqual(t, "annotation argument kind", model.ValueKindIdentifier, http.Arguments[0].Kind)
+	ExpectEqual(t, "annotation argument", "GET", http.Arguments[0].Text)
+	ExpectEqual(t, "annotation argument", "/entries/{id}", http.Arguments[1].Text)
+	ExpectEqual(t, "parameter json name", "entry_id", method.Parameters[0].JSONName())
+	ExpectEqual(t, "parameter annotated", true, method.Parameters[1].Annotations.Has("flag"))
+	ExpectEqual(t, "return type", "Entry", method.ReturnType.String())
+}
+
+func TestParserParsesDefaultValues(t *testing.T) {
+	source := `
+model Page {
+	size int = 20
+	label string? = "all" @json("name")
+	order Order
+}
+
+rpc List(pageSize int = 20, makeOwner bool? = false, order Order = Newest) Page`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	m := def.Models[0]
+	ExpectEqual(t, "field count", 3, len(m.Fields))
+	ExpectEqual(t, "field default", "20", m.Fields[0].Default.Text)
+	ExpectEqual(t, "field default kind", model.ValueKindNumber, m.Fields[0].Default.Kind)
+	ExpectEqual(t, "field default", "all", m.Fields[1].Default.Text)
+	ExpectEqual(t, "field json name", "name", m.Fields[1].JSONName())
+	ExpectEqual(t, "field has default", false, m.Fields[2].Default != nil)
+	ExpectEqual(t, "model has defaults", true, m.HasDefaults())
+
+	method := def.Methods[0]
+	ExpectEqual(t, "parameter count", 3, len(method.Parameters))
+	ExpectEqual(t, "parameter default", "20", method.Parameters[0].Default.Text)
+	ExpectEqual(t, "parameter type", "bool?", method.Parameters[1].Type.String())
+	ExpectEqual(t, "parameter default", "false", method.Parameters[1].Default.Text)
+	ExpectEqual(t, "parameter default kind", model.ValueKindIdentifier, method.Parameters[2].Default.Kind)
+	ExpectEqual(t, "return type", "Page", method.ReturnType.String())
+}
+
+func TestParserParsesErrors(t *testing.T) {
+	source := `
+/// The entry doesn't exist.
+@status(404)
+error NotFound {
+	id uuid
+}
+
+error Forbidden {}
+
+rpc GetEntry(id uuid) Entry throws NotFound, Forbidden
+rpc DeleteEntry(id uuid) throws NotFound
+rpc Ping()`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "error count", 2, len(def.Errors))
+	ExpectEqual(t, "error name", "NotFound", def.Errors[0].Name)
+	ExpectEqual(t, "error doc", "The entry doesn't exist.", def.Errors[0].Doc)
+	ExpectEqual(t, "error field count", 1, len(def.Errors[0].Fields))
+	ExpectEqual(t, "error status", 404, def.Errors[0].Status())
+	ExpectEqual(t, "default error status", 400, def.Errors[1].Status())
+
+	ExpectEqual(t, "method count", 3, len(def.Methods))
+	ExpectEqual(t, "return type", "Entry", def.Methods[0].ReturnType.String())
+	ExpectEqual(t, "throws", "NotFound,Forbidden", strings.Join(def.Methods[0].Throws, ","))
+	ExpectEqual(t, "return type", true, def.Methods[1].ReturnType == nil)
+	ExpectEqual(t, "throws", "NotFound", strings.Join(def.Methods[1].Throws, ","))
+	ExpectEqual(t, "throws", 0, len(def.Methods[2].Throws))
+}
+
+func TestParserParsesStreams(t *testing.T) {
+	source := `
+rpc WatchEntries(since date?) stream Entry[] throws NotFound
+rpc GetEntry() Entry`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "method count", 2, len(def.Methods))
+	ExpectEqual(t, "stream", true, def.Methods[0].Stream)
+	ExpectEqual(t, "return type", "Entry[]", def.Methods[0].ReturnType.String())
+	ExpectEqual(t, "throws", "NotFound", strings.Join(def.Methods[0].Throws, ","))
+	ExpectEqual(t, "stream", false, def.Methods[1].Stream)
+}
+
+func TestParserParsesBidirectionalStreams(t *testing.T) {
+	source := `
+rpc Edit(stream EditOperation) stream EditEvent throws NotFound
+rpc Watch() stream EditEvent`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ExpectEqual(t, "method count", 2, len(def.Methods))
+	ExpectEqual(t, "bidirectional", true, def.Methods[0].Bidirectional())
+	ExpectEqual(t, "stream parameter", "EditOperation", def.Methods[0].StreamParameter.String())
+	ExpectEqual(t, "parameter count", 0, len(def.Methods[0].Parameters))
+	ExpectEqual(t, "return type", "EditEvent", def.Methods[0].ReturnType.String())
+	ExpectEqual(t, "bidirectional", false, def.Methods[1].Bidirectional())
+}
+
+func TestParserRejectsBidirectionalStreamWithoutStreamReturn(t *testing.T) {
+	source := `rpc Edit(stream EditOperation) EditEvent`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = p.Parse()
+	if err == nil {
+		t.Error("expected an error for a bidirectional RPC without a stream return type")
+	}
+}
+
+func TestParserParsesRoutes(t *testing.T) {
+	source := `
+@http(GET, "/entries/{id}/versions/{version}")
+rpc GetEntry(id uuid, version int, verbose bool?, fields string[]) Entry
+@http(PATCH, "/entries/{id}")
+rpc UpdateEntry(id uuid, title string) Entry
+service Journal {
+	rpc ListEntries(limit int) Entry[]
+	rpc Edit(stream EntryEdit) stream EntryEdit
+}`
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cases := []struct {
+		method   model.Method
+		route    model.Route
+		hasRoute bool
+		hasBody  bool
+		vars     string
+		query    string
+	}{
+		{def.Methods[0], model.Route{Method: "GET", Path: "/entries/{id}/versions/{version}"}, true, false, "id version", "verbose fields"},
+		{def.Methods[1], model.Route{Method: "PATCH", Path: "/entries/{id}"}, true, true, "id", ""},
+		{def.Methods[2], model.Route{Method: "POST", Path: "/journal/list_entries"}, false, true, "", ""},
+		{def.Methods[3], model.Route{Method: "GET", Path: "/journal/edit"}, false, false, "", ""},
+	}
+	for _, c := range cases {
+		route := c.method.Route()
+		query := make([]string, 0)
+		for _, p := range c.method.QueryParameters() {
+			query = append(query, p.Name)
+		}
+
+		ExpectEqual(t, c.method.Name+" route", c.route, route)
+		ExpectEqual(t, c.method.Name+" has route", c.hasRoute, c.method.HasRoute())
+		ExpectEqual(t, c.method.Name+" has body", c.hasBody, route.HasBody())
+		ExpectEqual(t, c.method.Name+" path variables", c.vars, strings.Join(route.Variables(), " "))
+		ExpectEqual(t, c.method.Name+" query parameters", c.query, strings.Join(query, " "))
+	}
+}
+
+func TestParserReportsTokenizerProblems(t *testing.T) {
+	source := "model A {\n    id uuid\n}\n/* never closed"
+
+	p, err := NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = p.Parse()
+	if err == nil || err.Error() != "(3:0): unterminated block comment" {
+		t.Errorf("expected the unterminated block comment to be reported, but got %v", err)
+	}
+}
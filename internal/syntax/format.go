@@ -0,0 +1,602 @@
+package syntax
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fireland15/rpc-gen/internal/lexing"
+)
+
+const indentUnit = "    "
+
+// Prints a definition file in the canonical style, keeping its comments:
+//   - declarations are separated by a blank line, and runs of imports are
+//     sorted by path
+//   - members are indented by four spaces, one to a line, with the columns of
+//     fields, parameters and variants aligned
+//   - annotations put before a declaration go on lines of their own
+//   - at most one blank line is kept wherever blank lines were written
+//
+// The parameters of an RPC stay on one line unless they were written across
+// several. Files with syntax errors aren't formatted.
+func Format(source string) (string, error) {
+	file := Parse(source)
+	if len(file.Errors) > 0 {
+		messages := make([]string, len(file.Errors))
+		for idx, err := range file.Errors {
+			messages[idx] = err.Error()
+		}
+		return "", errors.New(strings.Join(messages, "\n"))
+	}
+
+	f := formatter{}
+	f.file(file)
+	return f.sb.String(), nil
+}
+
+type formatter struct {
+	sb strings.Builder
+}
+
+func (f *formatter) file(file *File) {
+	declarations := file.Nodes()
+	leads := make([]leading, len(declarations))
+	for idx, decl := range declarations {
+		leads[idx] = leadingOf(decl.AllTokens()[0].Leading)
+	}
+
+	// runs of imports without blank lines or comments between them are
+	// sorted. The comments before a run stay at its start.
+	for start := 0; start < len(declarations); start++ {
+		end := start + 1
+		for end < len(declarations) && declarations[start].Kind == NodeImport && declarations[end].Kind == NodeImport && leads[end].empty() {
+			end++
+		}
+		slices.SortStableFunc(declarations[start:end], func(a, b *Node) int {
+			return strings.Compare(importPath(a), importPath(b))
+		})
+		start = end - 1
+	}
+
+	for idx, decl := range declarations {
+		blank := idx > 0 && (leads[idx].blank || decl.Kind != NodeImport || declarations[idx-1].Kind != NodeImport)
+		f.lines("", blank, leads[idx].lines)
+		f.declaration(decl, "")
+	}
+
+	f.closing("", leadingOf(file.Trailing), len(declarations) > 0)
+}
+
+func importPath(n *Node) string {
+	tokens := n.Tokens()
+	return tokens[len(tokens)-1].Text
+}
+
+// writes a declaration or an RPC in a service. The comments before its first
+// token have already been written.
+func (f *formatter) declaration(n *Node, indent string) {
+	children := n.Children
+	first := true
+	for len(children) > 0 {
+		annotation, ok := children[0].(*Node)
+		if !ok || annotation.Kind != NodeAnnotation {
+			break
+		}
+
+		tokens := annotation.AllTokens()
+		if !first {
+			f.comments(indent, tokens[0])
+		}
+		f.line(indent, tokens, nil)
+		children = children[1:]
+		first = false
+	}
+
+	if !first {
+		f.comments(indent, children[0].(*Token))
+	}
+
+	switch n.Kind {
+	case NodeImport:
+		f.line(indent, tokensOf(children), nil)
+	case NodeRpc:
+		f.rpc(children, indent)
+	default:
+		f.block(n.Kind, children, indent)
+	}
+}
+
+// writes a declaration with a body, from its keyword on
+func (f *formatter) block(kind NodeKind, children []Element, indent string) {
+	open := slices.IndexFunc(children, func(e Element) bool {
+		tok, ok := e.(*Token)
+		return ok && tok.Type == lexing.TokenTypeLeftBracket
+	})
+	header := tokensOf(children[:open+1])
+	body := children[open+1 : len(children)-1]
+	close := children[len(children)-1].(*Token)
+
+	end := leadingOf(close.Leading)
+	if len(body) == 0 && !hasComments(header[open].Trailing) && len(end.lines) == 0 {
+		f.line(indent, append(header, close), nil)
+		return
+	}
+
+	f.line(indent, header, nil)
+	inner := indent + indentUnit
+	switch kind {
+	case NodeModel, NodeError:
+		rows := make([]row, 0)
+		for _, e := range body {
+			rows = append(rows, fieldRow(e.(*Node), nil, len(rows) == 0, nil, inner))
+		}
+		f.rows(inner, rows)
+	case NodeUnion:
+		rows := make([]row, 0)
+		for _, e := range body {
+			variant := e.(*Node)
+			name := variant.Tokens()[0]
+			r := newRow(name, len(rows) == 0)
+			types := variant.NodesOf(NodeType)[0].AllTokens()
+			r.render(name, types[len(types)-1], nil, inner, []*Token{name}, types)
+			rows = append(rows, r)
+		}
+		f.rows(inner, rows)
+	case NodeEnum:
+		rows := make([]row, 0)
+		for idx, e := range body {
+			value, ok := e.(*Node)
+			if !ok {
+				continue
+			}
+
+			name := value.Tokens()[0]
+			r := newRow(name, len(rows) == 0)
+			if comma, ok := nextToken(body, idx, lexing.TokenTypeComma); ok {
+				r.render(name, comma, nil, inner, []*Token{name, comma})
+			} else {
+				r.render(name, name, nil, inner, []*Token{name})
+				r.cells[0] += ","
+			}
+			rows = append(rows, r)
+		}
+		f.rows(inner, rows)
+	case NodeService:
+		for idx, e := range body {
+			rpc := e.(*Node)
+			l := leadingOf(rpc.AllTokens()[0].Leading)
+			f.lines(inner, l.blank && idx > 0, l.lines)
+			f.declaration(rpc, inner)
+		}
+	}
+
+	f.closing(inner, end, len(body) > 0)
+	f.line(indent, []*Token{close}, nil)
+}
+
+// writes an RPC from its keyword on. Its parameters go one to a line when
+// they were written across several lines.
+func (f *formatter) rpc(children []Element, indent string) {
+	open := slices.IndexFunc(children, func(e Element) bool {
+		tok, ok := e.(*Token)
+		return ok && tok.Type == lexing.TokenTypeLeftParenthesis
+	})
+	close := slices.IndexFunc(children, func(e Element) bool {
+		tok, ok := e.(*Token)
+		return ok && tok.Type == lexing.TokenTypeRightParenthesis
+	})
+
+	// a comma after the last parameter is left out
+	skip := make(map[*Token]bool)
+	if comma, ok := children[close-1].(*Token); ok && comma.Type == lexing.TokenTypeComma {
+		skip[comma] = true
+	}
+
+	parameters := tokensOf(children[open+1 : close])
+	inside := triviaText(children[open].(*Token).Trailing) + triviaText(children[close].(*Token).Leading)
+	for _, tok := range parameters {
+		inside += triviaText(tok.Leading) + triviaText(tok.Trailing)
+	}
+	multiline := strings.Contains(inside, "\n")
+	if len(parameters) == 0 {
+		// an empty list only needs lines for its comments
+		multiline = hasComments(children[open].(*Token).Trailing) || hasComments(children[close].(*Token).Leading)
+	}
+
+	if !multiline {
+		f.line(indent, tokensOf(children), skip)
+		return
+	}
+
+	f.line(indent, tokensOf(children[:open+1]), nil)
+	inner := indent + indentUnit
+	rows := make([]row, 0)
+	if len(parameters) > 0 && parameters[0].Text == "stream" {
+		r := newRow(parameters[0], true)
+		r.render(parameters[0], parameters[len(parameters)-1], nil, inner, parameters)
+		rows = append(rows, r)
+	} else {
+		body := children[open+1 : close]
+		for idx, e := range body {
+			parameter, ok := e.(*Node)
+			if !ok {
+				continue
+			}
+
+			comma, _ := nextToken(body, idx, lexing.TokenTypeComma)
+			rows = append(rows, fieldRow(parameter, comma, len(rows) == 0, skip, inner))
+		}
+	}
+	f.rows(inner, rows)
+
+	f.closing(inner, leadingOf(children[close].(*Token).Leading), len(rows) > 0)
+	f.line(indent, tokensOf(children[close:]), nil)
+}
+
+// A line of a block, whose cells are aligned with those of the lines around it.
+type row struct {
+	// whether a blank line comes before the row
+	blank bool
+	// the comments and annotations written on lines of their own before the
+	// row, with "" for blank lines
+	above    []string
+	cells    []string
+	trailing string
+	// whether there are comments between the row's first and last tokens
+	inline bool
+}
+
+// returns a row starting with tok, with the comments before it above it
+func newRow(tok *Token, first bool) row {
+	l := leadingOf(tok.Leading)
+	return row{blank: l.blank && !first, above: l.lines}
+}
+
+// renders each group of tokens into a cell. first and last are the row's
+// first and last tokens, whose comments go around the row.
+func (r *row) render(first *Token, last *Token, skip map[*Token]bool, indent string, groups ...[]*Token) {
+	for _, tokens := range groups {
+		for _, tok := range tokens {
+			if tok != first && hasComments(tok.Leading) || tok != last && hasComments(tok.Trailing) {
+				r.inline = true
+			}
+		}
+		if len(tokens) > 0 {
+			r.cells = append(r.cells, renderTokens(tokens, first, last, skip, indent))
+		}
+	}
+	r.trailing = trailingText(last)
+}
+
+// returns the row of a field or parameter, which is followed by comma when it
+// isn't nil. Its leading annotations go above it.
+func fieldRow(n *Node, comma *Token, first bool, skip map[*Token]bool, indent string) row {
+	r := newRow(n.AllTokens()[0], first)
+
+	var name *Token
+	var typ []*Token
+	rest := make([]*Token, 0)
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *Token:
+			name = c
+		case *Node:
+			switch {
+			case c.Kind == NodeAnnotation && name == nil:
+				tokens := c.AllTokens()
+				if tokens[0] != n.AllTokens()[0] {
+					r.above = append(r.above, commentLines(tokens[0])...)
+				}
+				last := tokens[len(tokens)-1]
+				r.above = append(r.above, renderTokens(tokens, tokens[0], last, nil, indent)+trailingText(last))
+			case c.Kind == NodeType:
+				typ = c.AllTokens()
+			default:
+				rest = append(rest, c.AllTokens()...)
+			}
+		}
+	}
+
+	if name != n.AllTokens()[0] {
+		r.above = append(r.above, commentLines(name)...)
+	}
+
+	// the comma goes at the end of the last cell, unless it's left out, in
+	// which case only its comments are kept
+	dropped := comma != nil && skip[comma]
+	if comma != nil && !dropped {
+		if len(rest) > 0 {
+			rest = append(rest, comma)
+		} else {
+			typ = append(typ, comma)
+		}
+	}
+
+	last := typ[len(typ)-1]
+	if len(rest) > 0 {
+		last = rest[len(rest)-1]
+	}
+	r.render(name, last, skip, indent, []*Token{name}, typ, rest)
+	if dropped {
+		for _, t := range append(comma.Leading, comma.Trailing...) {
+			if t.IsComment() {
+				r.trailing += " " + commentText(t.Text)
+			}
+		}
+	}
+	return r
+}
+
+// writes rows, aligning the cells of the rows which aren't separated by blank
+// lines. Rows with comments between their cells aren't aligned.
+func (f *formatter) rows(indent string, rows []row) {
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && !rows[end].blank && !rows[end].inline && !rows[start].inline {
+			end++
+		}
+
+		widths := make([]int, 0)
+		for _, r := range rows[start:end] {
+			for c, cell := range r.cells[:len(r.cells)-1] {
+				if c == len(widths) {
+					widths = append(widths, 0)
+				}
+				widths[c] = max(widths[c], utf8.RuneCountInString(cell))
+			}
+		}
+
+		for _, r := range rows[start:end] {
+			f.lines(indent, r.blank, r.above)
+
+			line := strings.Builder{}
+			for c, cell := range r.cells {
+				line.WriteString(cell)
+				if c < len(r.cells)-1 {
+					if r.inline {
+						// a cell ending in a line comment already ends in the
+						// indent of the next line
+						if !strings.HasSuffix(cell, " ") {
+							line.WriteString(" ")
+						}
+					} else {
+						line.WriteString(strings.Repeat(" ", widths[c]-utf8.RuneCountInString(cell)+1))
+					}
+				}
+			}
+			f.sb.WriteString(indent + line.String() + r.trailing + "\n")
+		}
+		start = end
+	}
+}
+
+// writes tokens on a line of their own. The comments before the first token
+// are written by the caller.
+func (f *formatter) line(indent string, tokens []*Token, skip map[*Token]bool) {
+	last := tokens[len(tokens)-1]
+	f.sb.WriteString(indent + renderTokens(tokens, tokens[0], last, skip, indent) + trailingText(last) + "\n")
+}
+
+// writes the comments on lines of their own before tok
+func (f *formatter) comments(indent string, tok *Token) {
+	l := leadingOf(tok.Leading)
+	f.lines(indent, l.blank, l.lines)
+}
+
+// writes the comments after the last member of a block, or at the end of the
+// file. The blank line before them is kept when something comes before them.
+func (f *formatter) closing(indent string, l leading, members bool) {
+	lines := trimBlank(l.lines)
+	if len(lines) > 0 {
+		f.lines(indent, l.blank && members, lines)
+	}
+}
+
+func (f *formatter) lines(indent string, blank bool, lines []string) {
+	if blank {
+		f.sb.WriteString("\n")
+	}
+	for _, line := range lines {
+		if line == "" {
+			f.sb.WriteString("\n")
+		} else {
+			f.sb.WriteString(indent + line + "\n")
+		}
+	}
+}
+
+// returns the tokens on a line, spaced canonically along with the comments
+// between them. The comments before first and after last are left out, and
+// the text of the tokens in skip is left out, but not their comments. A line
+// comment continues the line on the next one, indented past indent.
+func renderTokens(tokens []*Token, first *Token, last *Token, skip map[*Token]bool, indent string) string {
+	sb := strings.Builder{}
+	var prev *Token
+	// whether the last thing written was a comment, and whether it ended the
+	// line
+	afterComment, afterLine := false, false
+
+	comment := func(trivia []Trivia) {
+		for _, t := range trivia {
+			if !t.IsComment() {
+				continue
+			}
+			if sb.Len() > 0 && !afterLine {
+				sb.WriteString(" ")
+			}
+			text := commentText(t.Text)
+			sb.WriteString(text)
+			afterComment, afterLine = true, false
+			if strings.HasPrefix(text, "//") {
+				sb.WriteString("\n" + indent + indentUnit)
+				afterLine = true
+			}
+		}
+	}
+
+	for _, tok := range tokens {
+		if tok != first {
+			comment(tok.Leading)
+		}
+
+		if !skip[tok] {
+			if !afterLine && (afterComment && !closing(tok) || !afterComment && prev != nil && spaced(prev, tok)) {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(tok.Text)
+			prev = tok
+			afterComment, afterLine = false, false
+		}
+
+		if tok != last {
+			comment(tok.Trailing)
+		}
+	}
+	return sb.String()
+}
+
+// returns whether a space goes between two tokens on a line
+func spaced(prev *Token, tok *Token) bool {
+	switch prev.Type {
+	case lexing.TokenTypeAt, lexing.TokenTypeLeftParenthesis, lexing.TokenTypeLeftAngleBracket, lexing.TokenTypeLeftSquareBracket:
+		return false
+	case lexing.TokenTypeLeftBracket:
+		return tok.Type != lexing.TokenTypeRightBracket
+	}
+
+	if closing(tok) {
+		return false
+	}
+	switch tok.Type {
+	case lexing.TokenTypeLeftSquareBracket, lexing.TokenTypeLeftAngleBracket:
+		return false
+	case lexing.TokenTypeLeftParenthesis:
+		return prev.Type != lexing.TokenTypeIdentifier
+	}
+	return true
+}
+
+// returns whether tok ends what comes before it, so it's never spaced from
+// it, even when that's a comment
+func closing(tok *Token) bool {
+	switch tok.Type {
+	case lexing.TokenTypeRightParenthesis, lexing.TokenTypeRightSquareBracket, lexing.TokenTypeRightAngleBracket,
+		lexing.TokenTypeComma, lexing.TokenTypeQuestion:
+		return true
+	}
+	return false
+}
+
+// The comments written on lines of their own before a token.
+type leading struct {
+	// whether a blank line comes before the first comment, or before the
+	// token when there are none
+	blank bool
+	// the comments, with "" for a blank line after one
+	lines []string
+}
+
+func (l leading) empty() bool {
+	return !l.blank && len(l.lines) == 0
+}
+
+func leadingOf(trivia []Trivia) leading {
+	l := leading{lines: make([]string, 0)}
+	newlines := 0
+	blank := func() {
+		if newlines < 2 {
+			return
+		}
+		if len(l.lines) == 0 {
+			l.blank = true
+		} else {
+			l.lines = append(l.lines, "")
+		}
+	}
+
+	for _, t := range trivia {
+		if t.Kind == TriviaWhitespace {
+			newlines += strings.Count(t.Text, "\n")
+		} else if t.IsComment() {
+			blank()
+			l.lines = append(l.lines, commentText(t.Text))
+			newlines = 0
+		}
+	}
+	blank()
+	return l
+}
+
+// returns the comments before tok as lines, with a blank line before them when
+// there was one
+func commentLines(tok *Token) []string {
+	l := leadingOf(tok.Leading)
+	if l.blank {
+		return append([]string{""}, l.lines...)
+	}
+	return l.lines
+}
+
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// returns the comments after tok on its line, with a space before them
+func trailingText(tok *Token) string {
+	sb := strings.Builder{}
+	for _, t := range tok.Trailing {
+		if t.IsComment() {
+			sb.WriteString(" " + commentText(t.Text))
+		}
+	}
+	return sb.String()
+}
+
+// returns a comment without the whitespace at the ends of its lines
+func commentText(text string) string {
+	lines := strings.Split(text, "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hasComments(trivia []Trivia) bool {
+	return slices.ContainsFunc(trivia, Trivia.IsComment)
+}
+
+func triviaText(trivia []Trivia) string {
+	sb := strings.Builder{}
+	for _, t := range trivia {
+		sb.WriteString(t.Text)
+	}
+	return sb.String()
+}
+
+func tokensOf(elements []Element) []*Token {
+	tokens := make([]*Token, 0)
+	for _, e := range elements {
+		switch c := e.(type) {
+		case *Token:
+			tokens = append(tokens, c)
+		case *Node:
+			tokens = append(tokens, c.AllTokens()...)
+		}
+	}
+	return tokens
+}
+
+// returns the element after elements[idx] when it's a token of type tt
+func nextToken(elements []Element, idx int, tt lexing.TokenType) (*Token, bool) {
+	if idx+1 < len(elements) {
+		if tok, ok := elements[idx+1].(*Token); ok && tok.Type == tt {
+			return tok, true
+		}
+	}
+	return nil, false
+}
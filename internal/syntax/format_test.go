@@ -0,0 +1,208 @@
+package syntax
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fireland15/rpc-gen/internal/model"
+	"github.com/fireland15/rpc-gen/internal/parser"
+)
+
+var formatCases = []struct {
+	name     string
+	source   string
+	expected string
+}{
+	{
+		name:   "aligns fields",
+		source: "model A {\n  id uuid   \n\tcreatedOn date // created\n  name string = \"x\" @maxLength(10)\n\n  tags string[]?\n}",
+		expected: `model A {
+    id        uuid
+    createdOn date // created
+    name      string = "x" @maxLength(10)
+
+    tags string[]?
+}
+`,
+	},
+	{
+		name:   "sorts imports",
+		source: "// imports\nimport \"z.rpc\"\nimport \"a.rpc\"\n\nimport \"m.rpc\"\nimport \"b.rpc\"\nmodel A {}",
+		expected: `// imports
+import "a.rpc"
+import "z.rpc"
+
+import "b.rpc"
+import "m.rpc"
+
+model A {}
+`,
+	},
+	{
+		name:   "spaces declarations",
+		source: "\n\nenum E { A, /// b\n B }\nunion U ( kind ) { a A\n bb B }\n\n\n\n// section\n\n\n/// doc\n@status(404) @deprecated\nerror NotFound { id uuid }\n// the end\n\n",
+		expected: `enum E {
+    A,
+    /// b
+    B,
+}
+
+union U(kind) {
+    a  A
+    bb B
+}
+
+// section
+
+/// doc
+@status(404)
+@deprecated
+error NotFound {
+    id uuid
+}
+// the end
+`,
+	},
+	{
+		name:   "formats rpcs",
+		source: "service S{\n\n  @http(GET,\"/a/{id}\")\n  rpc Get( id uuid,limit int=5, ) map< string,A[] >? throws E,F\n  rpc Edit( stream A ) stream A\n\n\n  rpc Put(\n    /// the id\n    id uuid, // id\n    @deprecated value string @minLength(1),\n  )\n}\nrpc Ping(\n)",
+		expected: `service S {
+    @http(GET, "/a/{id}")
+    rpc Get(id uuid, limit int = 5) map<string, A[]>? throws E, F
+    rpc Edit(stream A) stream A
+
+    rpc Put(
+        /// the id
+        id    uuid, // id
+        @deprecated
+        value string @minLength(1)
+    )
+}
+
+rpc Ping()
+`,
+	},
+	{
+		name:   "keeps comments in odd places",
+		source: "model A { // fields\n  /* before */ id /* between */ uuid\n  // after\n}\nrpc B( // none\n)",
+		expected: `model A { // fields
+    /* before */
+    id /* between */ uuid
+    // after
+}
+
+rpc B( // none
+)
+`,
+	},
+	{
+		name:   "doesn't space comments from what follows them",
+		source: "enum Color { Red /* r */, Green }\nrpc Paint(color Color /* c */, times int /* t */) Color /* result */?",
+		expected: `enum Color {
+    Red /* r */,
+    Green,
+}
+
+rpc Paint(color Color /* c */, times int /* t */) Color /* result */?
+`,
+	},
+}
+
+func TestFormat(t *testing.T) {
+	for _, c := range formatCases {
+		formatted, err := Format(c.source)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		ExpectEqual(t, c.name, c.expected, formatted)
+	}
+}
+
+func TestFormatIsIdempotent(t *testing.T) {
+	for _, c := range formatCases {
+		formatted, err := Format(c.expected)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		ExpectEqual(t, c.name, c.expected, formatted)
+	}
+}
+
+func TestFormatKeepsMeaning(t *testing.T) {
+	for _, c := range formatCases {
+		formatted, err := Format(c.source)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+		ExpectEqual(t, c.name, definitionOf(t, c.source), definitionOf(t, formatted))
+	}
+}
+
+func TestFormatRejectsSyntaxErrors(t *testing.T) {
+	_, err := Format("model A {\n    id uuid[\n}\n")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	ExpectEqual(t, "error", `(2:0): expected "]", but found "}"`, err.Error())
+}
+
+// returns the parsed definition, without the positions of its declarations
+// and their members.
+// Imports are sorted, so their order is left out too.
+func definitionOf(t *testing.T, source string) string {
+	p, err := parser.NewParser(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	def, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clearFields := func(fields []model.Field) {
+		for idx := range fields {
+			fields[idx].Source = model.Source{}
+		}
+	}
+
+	for idx := range def.Models {
+		def.Models[idx].Source = model.Source{}
+		clearFields(def.Models[idx].Fields)
+	}
+	for idx := range def.Enums {
+		def.Enums[idx].Source = model.Source{}
+		for v := range def.Enums[idx].Values {
+			def.Enums[idx].Values[v].Source = model.Source{}
+		}
+	}
+	for idx := range def.Unions {
+		def.Unions[idx].Source = model.Source{}
+		for v := range def.Unions[idx].Variants {
+			def.Unions[idx].Variants[v].Source = model.Source{}
+		}
+	}
+	for idx := range def.Errors {
+		def.Errors[idx].Source = model.Source{}
+		clearFields(def.Errors[idx].Fields)
+	}
+	for idx := range def.Services {
+		def.Services[idx].Source = model.Source{}
+	}
+	for idx := range def.Methods {
+		def.Methods[idx].Source = model.Source{}
+		for p := range def.Methods[idx].Parameters {
+			def.Methods[idx].Parameters[p].Source = model.Source{}
+		}
+	}
+	for idx := range def.Imports {
+		def.Imports[idx].Source = model.Source{}
+	}
+	slices.SortFunc(def.Imports, func(a, b model.Import) int { return strings.Compare(a.Path, b.Path) })
+	return fmt.Sprintf("%+v", def)
+}
@@ -0,0 +1,432 @@
+package syntax
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/fireland15/rpc-gen/internal/lexing"
+	"github.com/fireland15/rpc-gen/internal/parser"
+)
+
+// Parses a definition file into its syntax tree. It accepts the same grammar
+// as parser.Parser, but keeps every token and comment so that the source can
+// be printed back. Parsing carries on past errors, putting the tokens it can't
+// make sense of in invalid nodes.
+func Parse(source string) *File {
+	tokens, trailing, errors := scan(source)
+	p := &treeParser{tokens: tokens, errors: errors}
+
+	file := &File{Node: &Node{Kind: NodeFile}, Trailing: trailing}
+	for p.peek(0) != nil {
+		start := p.pos
+		file.add(p.declaration())
+		if p.pos == start {
+			p.invalid(file.Node)
+		}
+	}
+
+	file.Errors = p.errors
+	slices.SortStableFunc(file.Errors, func(a, b Error) int {
+		return a.Span.Start.Offset - b.Span.Start.Offset
+	})
+	return file
+}
+
+type treeParser struct {
+	tokens []*Token
+	pos    int
+	errors []Error
+}
+
+var keywords = []parser.Keyword{
+	parser.KwImport,
+	parser.KwService,
+	parser.KwModel,
+	parser.KwEnum,
+	parser.KwUnion,
+	parser.KwRpc,
+	parser.KwOptional,
+	parser.KwError,
+	parser.KwThrows,
+	parser.KwStream,
+}
+
+func (p *treeParser) declaration() *Node {
+	tok := p.peek(p.pastAnnotations())
+	if tok != nil && tok.Type == lexing.TokenTypeIdentifier {
+		switch parser.Keyword(tok.Text) {
+		case parser.KwImport:
+			return p.importDeclaration()
+		case parser.KwModel:
+			return p.modelDeclaration(NodeModel, parser.KwModel)
+		case parser.KwError:
+			return p.modelDeclaration(NodeError, parser.KwError)
+		case parser.KwEnum:
+			return p.enumDeclaration()
+		case parser.KwUnion:
+			return p.unionDeclaration()
+		case parser.KwService:
+			return p.serviceDeclaration()
+		case parser.KwRpc:
+			return p.rpcDeclaration()
+		}
+	}
+
+	// the annotations go along with the token they were put on
+	invalid := &Node{Kind: NodeInvalid}
+	for p.peek(0) != tok {
+		invalid.add(p.next())
+	}
+	p.errorf("expected keyword \"import\", \"model\", \"enum\", \"union\", \"error\", \"service\" or \"rpc\", but found %s", found(tok))
+	if tok != nil {
+		invalid.add(p.next())
+	}
+	return invalid
+}
+
+func (p *treeParser) importDeclaration() *Node {
+	n := &Node{Kind: NodeImport}
+	if p.at(lexing.TokenTypeAt) {
+		p.errorf("imports can't have annotations")
+		p.annotations(n, -1)
+	}
+
+	if p.keyword(n, parser.KwImport) {
+		p.expect(n, lexing.TokenTypeString)
+	}
+	return n
+}
+
+// parses a model or an error, which are both a list of fields
+func (p *treeParser) modelDeclaration(kind NodeKind, kw parser.Keyword) *Node {
+	n := &Node{Kind: kind}
+	p.annotations(n, -1)
+	if !p.keyword(n, kw) || !p.expect(n, lexing.TokenTypeIdentifier) || !p.expect(n, lexing.TokenTypeLeftBracket) {
+		return n
+	}
+
+	p.members(n, func() bool {
+		return p.at(lexing.TokenTypeIdentifier) || p.at(lexing.TokenTypeAt)
+	}, func() *Node {
+		return p.field(NodeField)
+	})
+	p.expect(n, lexing.TokenTypeRightBracket)
+	return n
+}
+
+func (p *treeParser) enumDeclaration() *Node {
+	n := &Node{Kind: NodeEnum}
+	p.annotations(n, -1)
+	if !p.keyword(n, parser.KwEnum) || !p.expect(n, lexing.TokenTypeIdentifier) || !p.expect(n, lexing.TokenTypeLeftBracket) {
+		return n
+	}
+
+	for p.at(lexing.TokenTypeIdentifier) {
+		value := &Node{Kind: NodeEnumValue}
+		value.add(p.next())
+		n.add(value)
+
+		if !p.at(lexing.TokenTypeComma) {
+			break
+		}
+		n.add(p.next())
+	}
+	p.expect(n, lexing.TokenTypeRightBracket)
+	return n
+}
+
+func (p *treeParser) unionDeclaration() *Node {
+	n := &Node{Kind: NodeUnion}
+	p.annotations(n, -1)
+	if !p.keyword(n, parser.KwUnion) || !p.expect(n, lexing.TokenTypeIdentifier) {
+		return n
+	}
+
+	// the discriminator
+	if !p.expect(n, lexing.TokenTypeLeftParenthesis) || !p.expect(n, lexing.TokenTypeIdentifier) || !p.expect(n, lexing.TokenTypeRightParenthesis) {
+		return n
+	}
+	if !p.expect(n, lexing.TokenTypeLeftBracket) {
+		return n
+	}
+
+	p.members(n, func() bool {
+		return p.at(lexing.TokenTypeIdentifier)
+	}, func() *Node {
+		variant := &Node{Kind: NodeVariant}
+		variant.add(p.next())
+		variant.add(p.typ())
+		return variant
+	})
+	p.expect(n, lexing.TokenTypeRightBracket)
+	return n
+}
+
+func (p *treeParser) serviceDeclaration() *Node {
+	n := &Node{Kind: NodeService}
+	p.annotations(n, -1)
+	if !p.keyword(n, parser.KwService) || !p.expect(n, lexing.TokenTypeIdentifier) || !p.expect(n, lexing.TokenTypeLeftBracket) {
+		return n
+	}
+
+	p.members(n, func() bool {
+		tok := p.peek(p.pastAnnotations())
+		return tok != nil && tok.Type == lexing.TokenTypeIdentifier && tok.Text == string(parser.KwRpc)
+	}, p.rpcDeclaration)
+	p.expect(n, lexing.TokenTypeRightBracket)
+	return n
+}
+
+func (p *treeParser) rpcDeclaration() *Node {
+	n := &Node{Kind: NodeRpc}
+	p.annotations(n, -1)
+	if !p.keyword(n, parser.KwRpc) || !p.expect(n, lexing.TokenTypeIdentifier) || !p.expect(n, lexing.TokenTypeLeftParenthesis) {
+		return n
+	}
+
+	// a bidirectional RPC takes the stream of messages from the client in place
+	// of its parameters
+	bidirectional := p.atKeyword(parser.KwStream)
+	if bidirectional {
+		n.add(p.next())
+		n.add(p.typ())
+	}
+
+	for !bidirectional && (p.at(lexing.TokenTypeIdentifier) || p.at(lexing.TokenTypeAt)) {
+		n.add(p.field(NodeParameter))
+		if !p.at(lexing.TokenTypeComma) {
+			break
+		}
+		n.add(p.next())
+	}
+
+	if !p.expect(n, lexing.TokenTypeRightParenthesis) {
+		return n
+	}
+
+	if p.atKeyword(parser.KwStream) {
+		n.add(p.next())
+		n.add(p.typ())
+	} else if bidirectional {
+		p.errorf("expected a stream return type for a bidirectional RPC, but found %s", found(p.peek(0)))
+		return n
+	} else if tok := p.peek(0); tok != nil && tok.Type == lexing.TokenTypeIdentifier && !isKeyword(tok.Text) {
+		n.add(p.typ())
+	}
+
+	if p.atKeyword(parser.KwThrows) {
+		throws := &Node{Kind: NodeThrows}
+		throws.add(p.next())
+		for p.expect(throws, lexing.TokenTypeIdentifier) && p.at(lexing.TokenTypeComma) {
+			throws.add(p.next())
+		}
+		n.add(throws)
+	}
+	return n
+}
+
+// parses a model field or an RPC parameter. The annotations written after it
+// have to start on the line of its name, the others are those of whatever
+// comes next.
+func (p *treeParser) field(kind NodeKind) *Node {
+	n := &Node{Kind: kind}
+	p.annotations(n, -1)
+
+	name := p.peek(0)
+	if !p.expect(n, lexing.TokenTypeIdentifier) {
+		return n
+	}
+	n.add(p.typ())
+
+	if p.at(lexing.TokenTypeEquals) {
+		value := &Node{Kind: NodeDefault}
+		value.add(p.next())
+		p.value(value)
+		n.add(value)
+	}
+
+	p.annotations(n, name.Span.Start.Line)
+	return n
+}
+
+func (p *treeParser) typ() *Node {
+	n := &Node{Kind: NodeType}
+	name := p.peek(0)
+	if !p.expect(n, lexing.TokenTypeIdentifier) {
+		return n
+	}
+
+	if name.Text == string(parser.KwMap) && p.at(lexing.TokenTypeLeftAngleBracket) {
+		n.add(p.next())
+		n.add(p.typ())
+		if !p.expect(n, lexing.TokenTypeComma) {
+			return n
+		}
+		n.add(p.typ())
+		if !p.expect(n, lexing.TokenTypeRightAngleBracket) {
+			return n
+		}
+	}
+
+	for {
+		if p.at(lexing.TokenTypeLeftSquareBracket) {
+			n.add(p.next())
+			if !p.expect(n, lexing.TokenTypeRightSquareBracket) {
+				return n
+			}
+		} else if p.at(lexing.TokenTypeQuestion) {
+			n.add(p.next())
+		} else {
+			return n
+		}
+	}
+}
+
+// adds the annotations at the current position to n. When line isn't negative
+// only the annotations starting on that line are added.
+func (p *treeParser) annotations(n *Node, line int) {
+	for p.at(lexing.TokenTypeAt) && (line < 0 || p.peek(0).Span.Start.Line == line) {
+		annotation := &Node{Kind: NodeAnnotation}
+		n.add(annotation)
+
+		annotation.add(p.next())
+		if !p.expect(annotation, lexing.TokenTypeIdentifier) || !p.at(lexing.TokenTypeLeftParenthesis) {
+			continue
+		}
+
+		annotation.add(p.next())
+		for !p.at(lexing.TokenTypeRightParenthesis) && p.value(annotation) && p.at(lexing.TokenTypeComma) {
+			annotation.add(p.next())
+		}
+		p.expect(annotation, lexing.TokenTypeRightParenthesis)
+	}
+}
+
+func (p *treeParser) value(n *Node) bool {
+	tok := p.peek(0)
+	if tok != nil && (tok.Type == lexing.TokenTypeString || tok.Type == lexing.TokenTypeNumber || tok.Type == lexing.TokenTypeIdentifier) {
+		n.add(p.next())
+		return true
+	}
+
+	p.errorf("expected a string, number or identifier, but found %s", found(tok))
+	return false
+}
+
+// adds the members of a block to n, up to its closing bracket. The tokens
+// which don't start a member are put in invalid nodes.
+func (p *treeParser) members(n *Node, isMember func() bool, member func() *Node) {
+	for p.peek(0) != nil && !p.at(lexing.TokenTypeRightBracket) {
+		start := p.pos
+		if isMember() {
+			n.add(member())
+		}
+
+		if p.pos == start {
+			p.errorf("unexpected %s", found(p.peek(0)))
+			p.invalid(n)
+		}
+	}
+}
+
+// moves the current token into an invalid node in n
+func (p *treeParser) invalid(n *Node) {
+	invalid := &Node{Kind: NodeInvalid}
+	invalid.add(p.next())
+	n.add(invalid)
+}
+
+// returns how many tokens the annotations at the current position take up
+func (p *treeParser) pastAnnotations() int {
+	n := 0
+	for {
+		tok := p.peek(n)
+		if tok == nil || tok.Type != lexing.TokenTypeAt {
+			return n
+		}
+
+		// skip the @ and the annotation's name
+		n += 2
+		tok = p.peek(n)
+		if tok == nil || tok.Type != lexing.TokenTypeLeftParenthesis {
+			continue
+		}
+
+		for tok != nil && tok.Type != lexing.TokenTypeRightParenthesis {
+			n += 1
+			tok = p.peek(n)
+		}
+		n += 1
+	}
+}
+
+func (p *treeParser) peek(n int) *Token {
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
+	}
+	return nil
+}
+
+func (p *treeParser) next() *Token {
+	tok := p.peek(0)
+	if tok != nil {
+		p.pos += 1
+	}
+	return tok
+}
+
+func (p *treeParser) at(tt lexing.TokenType) bool {
+	tok := p.peek(0)
+	return tok != nil && tok.Type == tt
+}
+
+func (p *treeParser) atKeyword(kw parser.Keyword) bool {
+	tok := p.peek(0)
+	return tok != nil && tok.Type == lexing.TokenTypeIdentifier && tok.Text == string(kw)
+}
+
+// adds the current token to n when it has the type tt, and reports an error
+// otherwise
+func (p *treeParser) expect(n *Node, tt lexing.TokenType) bool {
+	if p.at(tt) {
+		n.add(p.next())
+		return true
+	}
+
+	p.errorf("expected \"%s\", but found %s", tt, found(p.peek(0)))
+	return false
+}
+
+func (p *treeParser) keyword(n *Node, kw parser.Keyword) bool {
+	if p.atKeyword(kw) {
+		n.add(p.next())
+		return true
+	}
+
+	p.errorf("expected keyword \"%s\", but found %s", kw, found(p.peek(0)))
+	return false
+}
+
+// reports an error at the current token, or at the end of the last token when
+// there are none left
+func (p *treeParser) errorf(format string, args ...any) {
+	span := lexing.Span{}
+	if tok := p.peek(0); tok != nil {
+		span = tok.Span
+	} else if len(p.tokens) > 0 {
+		end := p.tokens[len(p.tokens)-1].Span.End
+		span = lexing.Span{Start: end, End: end}
+	}
+	p.errors = append(p.errors, Error{Span: span, Message: fmt.Sprintf(format, args...)})
+}
+
+func found(tok *Token) string {
+	if tok == nil {
+		return "the end of the file"
+	}
+	return fmt.Sprintf("\"%s\"", tok.Text)
+}
+
+func isKeyword(text string) bool {
+	return slices.Contains(keywords, parser.Keyword(text))
+}
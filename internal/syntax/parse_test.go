@@ -0,0 +1,80 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/fireland15/rpc-gen/internal/lexing"
+)
+
+func TestParseIsLossless(t *testing.T) {
+	sources := []string{
+		"",
+		"\n\n  ",
+		"// only a comment",
+		"model A {\n    id uuid // the id\n}\n",
+		"/// doc\n@deprecated(\"old\")\nrpc Get(\n    /// the id\n    id uuid @minLength(1),\n) stream A throws E, F\n",
+		"enum E { A, /// b\n B, }\r\nunion U(kind) { a A }\n/* block\n comment */",
+		"service S {\n\trpc A(stream B) stream C\n}\n\n\n",
+		// source with errors
+		"model A { id uuid[ }\nrpc $ B(\nimport",
+		"@deprecated",
+		"enum E { A B }\n}} \"unterminated\n",
+	}
+
+	for _, source := range sources {
+		file := Parse(source)
+		ExpectEqual(t, "printed source", source, file.String())
+	}
+}
+
+func TestParseBuildsTree(t *testing.T) {
+	file := Parse("model Entry {\n    @deprecated\n    tags string[]? = \"x\" @maxItems(2)\n}\n")
+	ExpectEqual(t, "error count", 0, len(file.Errors))
+
+	models := file.NodesOf(NodeModel)
+	ExpectEqual(t, "model count", 1, len(models))
+
+	name, ok := models[0].Name()
+	ExpectEqual(t, "has name", true, ok)
+	ExpectEqual(t, "model name", "Entry", name.Text)
+
+	fields := models[0].NodesOf(NodeField)
+	ExpectEqual(t, "field count", 1, len(fields))
+	ExpectEqual(t, "field annotation count", 2, len(fields[0].NodesOf(NodeAnnotation)))
+
+	typ, ok := fields[0].NodeOf(NodeType)
+	ExpectEqual(t, "has type", true, ok)
+	ExpectEqual(t, "type", "string[]?", typ.Text())
+	ExpectEqual(t, "type start", lexing.Position{Offset: 39, Line: 2, Column: 9}, typ.Span().Start)
+	ExpectEqual(t, "type end", lexing.Position{Offset: 48, Line: 2, Column: 18}, typ.Span().End)
+
+	value, ok := fields[0].NodeOf(NodeDefault)
+	ExpectEqual(t, "has default", true, ok)
+	ExpectEqual(t, "default", `= "x"`, value.Text())
+	ExpectEqual(t, "default with trivia", `= "x" `, value.String())
+}
+
+func TestParseAttachesTrivia(t *testing.T) {
+	file := Parse("a // same line\n// next line\nb")
+	tokens := file.AllTokens()
+	ExpectEqual(t, "token count", 2, len(tokens))
+	ExpectEqual(t, "trailing count", 2, len(tokens[0].Trailing))
+	ExpectEqual(t, "trailing comment", "// same line", tokens[0].Trailing[1].Text)
+	ExpectEqual(t, "leading count", 3, len(tokens[1].Leading))
+	ExpectEqual(t, "leading comment", "// next line", tokens[1].Leading[1].Text)
+}
+
+func TestParseReportsErrors(t *testing.T) {
+	file := Parse("model A {\n    id uuid[\n}\nrpc B() $\n}")
+	ExpectEqual(t, "error count", 3, len(file.Errors))
+	ExpectEqual(t, "first error", `(2:0): expected "]", but found "}"`, file.Errors[0].Error())
+	ExpectEqual(t, "second error", `(3:8): unrecognized characters "$"`, file.Errors[1].Error())
+	ExpectEqual(t, "third error", `(4:0): expected keyword "import", "model", "enum", "union", "error", "service" or "rpc", but found "}"`, file.Errors[2].Error())
+}
+
+func ExpectEqual[T comparable](t *testing.T, field string, expected T, actual T) {
+	if expected != actual {
+		t.Errorf("expected %s to be '%v', but got '%v'.", field, expected, actual)
+		t.Fail()
+	}
+}
@@ -0,0 +1,139 @@
+package syntax
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/fireland15/rpc-gen/internal/lexing"
+)
+
+// Splits source into tokens, attaching the whitespace and comments between
+// them as trivia, and returns the trivia after the last token. Characters the
+// tokenizer skips over are kept as skipped trivia, and the problems it finds
+// are reported as errors.
+func scan(source string) ([]*Token, []Trivia, []Error) {
+	tokens := make([]*Token, 0)
+	errors := make([]Error, 0)
+	if source == "" {
+		return tokens, nil, errors
+	}
+
+	tokenizer, err := lexing.NewTokenizer(strings.NewReader(source))
+	if err != nil {
+		errors = append(errors, Error{Message: err.Error()})
+		return tokens, []Trivia{{Kind: TriviaSkipped, Text: source}}, errors
+	}
+
+	text := []rune(source)
+	offset := 0
+	// the position of text[offset]
+	position := lexing.Position{}
+	pending := make([]Trivia, 0)
+
+	// adds the text from offset up to end to the pending trivia
+	gap := func(end int) {
+		for offset < end {
+			whitespace := unicode.Is(unicode.White_Space, text[offset])
+			next := offset + 1
+			for next < end && unicode.Is(unicode.White_Space, text[next]) == whitespace {
+				next++
+			}
+
+			trivia := Trivia{Kind: TriviaWhitespace, Text: string(text[offset:next])}
+			if !whitespace {
+				trivia.Kind = TriviaSkipped
+			}
+			pending = append(pending, trivia)
+			offset = next
+			position = advance(position, trivia.Text)
+		}
+	}
+
+	for {
+		tok, end := tokenizer.Next()
+		if tok.Text != "" {
+			gap(tok.Span.Start.Offset)
+			offset = tok.Span.Start.Offset + len([]rune(tok.Text))
+			position = advance(tok.Span.Start, tok.Text)
+
+			switch tok.Type {
+			case lexing.TokenTypeComment:
+				pending = append(pending, Trivia{Kind: TriviaComment, Text: tok.Text})
+			case lexing.TokenTypeDocComment:
+				pending = append(pending, Trivia{Kind: TriviaDocComment, Text: tok.Text})
+			default:
+				next := &Token{
+					Type: tok.Type,
+					Text: tok.Text,
+					Span: lexing.Span{Start: tok.Span.Start, End: position},
+				}
+				if len(tokens) > 0 {
+					tokens[len(tokens)-1].Trailing, pending = splitTrailing(pending)
+				}
+				next.Leading = pending
+				pending = make([]Trivia, 0)
+				tokens = append(tokens, next)
+			}
+		}
+
+		if end {
+			break
+		}
+	}
+	gap(len(text))
+
+	for _, e := range tokenizer.Errors() {
+		errors = append(errors, Error{Span: e.Span, Message: e.Message})
+	}
+
+	if len(tokens) > 0 {
+		tokens[len(tokens)-1].Trailing, pending = splitTrailing(pending)
+	}
+	return tokens, pending, errors
+}
+
+// splits the trivia after a token into the trivia on the rest of its line and
+// the trivia after that. Doc comments belong to the token after them, so they
+// aren't trailing trivia even when they're on the same line.
+func splitTrailing(trivia []Trivia) ([]Trivia, []Trivia) {
+	trailing := make([]Trivia, 0)
+	for idx, t := range trivia {
+		if t.Kind == TriviaDocComment {
+			return trailing, trivia[idx:]
+		}
+
+		newline := strings.IndexByte(t.Text, '\n')
+		if newline == -1 {
+			trailing = append(trailing, t)
+			continue
+		}
+
+		if t.Kind != TriviaWhitespace {
+			// a block comment starting on the line ends it
+			trailing = append(trailing, t)
+			return trailing, trivia[idx+1:]
+		}
+
+		rest := make([]Trivia, 0, len(trivia)-idx)
+		if newline > 0 {
+			trailing = append(trailing, Trivia{Kind: TriviaWhitespace, Text: t.Text[:newline]})
+		}
+		rest = append(rest, Trivia{Kind: TriviaWhitespace, Text: t.Text[newline:]})
+		return trailing, append(rest, trivia[idx+1:]...)
+	}
+	return trailing, make([]Trivia, 0)
+}
+
+// returns the position after text, which starts at start
+func advance(start lexing.Position, text string) lexing.Position {
+	position := start
+	for _, r := range text {
+		position.Offset += 1
+		position.Column += 1
+		if r == '\n' {
+			position.Line += 1
+			position.Column = 0
+		}
+	}
+	return position
+}
@@ -0,0 +1,280 @@
+package syntax
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fireland15/rpc-gen/internal/lexing"
+)
+
+type TriviaKind int
+
+const (
+	TriviaWhitespace TriviaKind = iota
+	TriviaComment
+	TriviaDocComment
+	// Characters the tokenizer doesn't recognize.
+	TriviaSkipped
+)
+
+// Text between tokens which doesn't affect the meaning of a definition.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
+
+func (t Trivia) IsComment() bool {
+	return t.Kind == TriviaComment || t.Kind == TriviaDocComment
+}
+
+// A token along with the trivia around it. A token's trailing trivia runs up
+// to the end of its line, and the rest comes before the next token.
+type Token struct {
+	Type lexing.TokenType
+	Text string
+	// The end is just past the token's last character.
+	Span     lexing.Span
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+type NodeKind int
+
+const (
+	NodeFile NodeKind = iota
+	NodeImport
+	NodeModel
+	NodeEnum
+	NodeUnion
+	NodeError
+	NodeService
+	NodeRpc
+	NodeField
+	NodeParameter
+	NodeEnumValue
+	NodeVariant
+	NodeAnnotation
+	NodeType
+	NodeDefault
+	NodeThrows
+	// Tokens which couldn't be parsed.
+	NodeInvalid
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case NodeFile:
+		return "file"
+	case NodeImport:
+		return "import"
+	case NodeModel:
+		return "model"
+	case NodeEnum:
+		return "enum"
+	case NodeUnion:
+		return "union"
+	case NodeError:
+		return "error"
+	case NodeService:
+		return "service"
+	case NodeRpc:
+		return "rpc"
+	case NodeField:
+		return "field"
+	case NodeParameter:
+		return "parameter"
+	case NodeEnumValue:
+		return "enum value"
+	case NodeVariant:
+		return "variant"
+	case NodeAnnotation:
+		return "annotation"
+	case NodeType:
+		return "type"
+	case NodeDefault:
+		return "default"
+	case NodeThrows:
+		return "throws"
+	case NodeInvalid:
+		return "invalid"
+	default:
+		panic("unknown node kind")
+	}
+}
+
+// A *Node or a *Token.
+type Element interface {
+	writeTo(sb *strings.Builder)
+}
+
+// A piece of a definition made up of tokens and other nodes, in the order they
+// were written.
+type Node struct {
+	Kind     NodeKind
+	Children []Element
+}
+
+// A parsed definition file. Writing out its tokens and trivia gives back the
+// source it was parsed from, even when the source has errors.
+type File struct {
+	*Node
+	// The whitespace and comments after the last token.
+	Trailing []Trivia
+	Errors   []Error
+}
+
+// A syntax error.
+type Error struct {
+	Span    lexing.Span
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("(%d:%d): %s", e.Span.Start.Line, e.Span.Start.Column, e.Message)
+}
+
+func (t *Token) writeTo(sb *strings.Builder) {
+	for _, trivia := range t.Leading {
+		sb.WriteString(trivia.Text)
+	}
+	sb.WriteString(t.Text)
+	for _, trivia := range t.Trailing {
+		sb.WriteString(trivia.Text)
+	}
+}
+
+// Returns the span from the start of the node's first token to the end of its
+// last. Empty nodes have an empty span.
+func (n *Node) Span() lexing.Span {
+	tokens := n.AllTokens()
+	if len(tokens) == 0 {
+		return lexing.Span{}
+	}
+	return lexing.Span{Start: tokens[0].Span.Start, End: tokens[len(tokens)-1].Span.End}
+}
+
+func (n *Node) writeTo(sb *strings.Builder) {
+	for _, child := range n.Children {
+		child.writeTo(sb)
+	}
+}
+
+// Returns the node's text, including the trivia around its tokens.
+func (n *Node) String() string {
+	sb := strings.Builder{}
+	n.writeTo(&sb)
+	return sb.String()
+}
+
+// Returns the node's text without the trivia before its first token and after
+// its last.
+func (n *Node) Text() string {
+	sb := strings.Builder{}
+	tokens := n.AllTokens()
+	for idx, tok := range tokens {
+		if idx > 0 {
+			for _, trivia := range tok.Leading {
+				sb.WriteString(trivia.Text)
+			}
+		}
+		sb.WriteString(tok.Text)
+		if idx < len(tokens)-1 {
+			for _, trivia := range tok.Trailing {
+				sb.WriteString(trivia.Text)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Returns the source the file was parsed from.
+func (f *File) String() string {
+	sb := strings.Builder{}
+	f.writeTo(&sb)
+	for _, trivia := range f.Trailing {
+		sb.WriteString(trivia.Text)
+	}
+	return sb.String()
+}
+
+// Returns the node's child nodes.
+func (n *Node) Nodes() []*Node {
+	nodes := make([]*Node, 0)
+	for _, child := range n.Children {
+		if node, ok := child.(*Node); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Returns the node's child nodes of the given kind.
+func (n *Node) NodesOf(kind NodeKind) []*Node {
+	nodes := make([]*Node, 0)
+	for _, node := range n.Nodes() {
+		if node.Kind == kind {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Returns the node's first child node of the given kind.
+func (n *Node) NodeOf(kind NodeKind) (*Node, bool) {
+	for _, node := range n.Nodes() {
+		if node.Kind == kind {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// Returns the tokens which are children of the node, leaving out those of its
+// child nodes.
+func (n *Node) Tokens() []*Token {
+	tokens := make([]*Token, 0)
+	for _, child := range n.Children {
+		if tok, ok := child.(*Token); ok {
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
+
+// Returns every token in the node, including those of its child nodes.
+func (n *Node) AllTokens() []*Token {
+	tokens := make([]*Token, 0)
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *Token:
+			tokens = append(tokens, c)
+		case *Node:
+			tokens = append(tokens, c.AllTokens()...)
+		}
+	}
+	return tokens
+}
+
+// Returns the identifier naming the declaration, field, parameter, enum value
+// or variant.
+func (n *Node) Name() (*Token, bool) {
+	tokens := n.Tokens()
+	switch n.Kind {
+	case NodeModel, NodeEnum, NodeUnion, NodeError, NodeService, NodeRpc:
+		// the name follows the keyword
+		if len(tokens) > 1 && tokens[1].Type == lexing.TokenTypeIdentifier {
+			return tokens[1], true
+		}
+	case NodeField, NodeParameter, NodeEnumValue, NodeVariant, NodeAnnotation:
+		for _, tok := range tokens {
+			if tok.Type == lexing.TokenTypeIdentifier {
+				return tok, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (n *Node) add(child Element) {
+	n.Children = append(n.Children, child)
+}